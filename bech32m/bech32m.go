@@ -108,9 +108,20 @@ func Encode(hrp string, data []byte, spec int) string {
 	return ret.String()
 }
 
-// Decode validate a Bech32/Bech32m string, and determine HRP and data.
+// MaxLength is the maximum length of Bech32/Bech32m strings for segwit addresses by BIP173.
+const MaxLength = 90
+
+// Decode validate a Bech32/Bech32m string of at most MaxLength characters, and determine HRP and data.
 func Decode(bechString string) (string, []byte, int, error) {
-	if len(bechString) > 90 {
+	return DecodeWithLimit(bechString, MaxLength)
+}
+
+// DecodeWithLimit validate a Bech32/Bech32m string of at most limit characters, and determine HRP and data.
+// If limit is not positive, the length is not limited.
+// The checksum guarantees to detect up to 4 errors only for the strings of at most MaxLength characters,
+// so that the longer strings like BOLT11 invoices are less protected.
+func DecodeWithLimit(bechString string, limit int) (string, []byte, int, error) {
+	if limit > 0 && len(bechString) > limit {
 		return "", nil, Failed, fmt.Errorf("Overall max length exceeded")
 	}
 	if strings.ToLower(bechString) != bechString && strings.ToUpper(bechString) != bechString {
@@ -151,14 +162,22 @@ func Decode(bechString string) (string, []byte, int, error) {
 	return hrp, data[:len(data)-6], spec, nil
 }
 
-func convertbits(data []byte, frombits, tobits uint, pad bool) ([]byte, error) {
-	// General power-of-2 base conversion.
+// ConvertBits is the general power-of-2 base conversion,
+// for example from 8-bit bytes to 5-bit groups with pad = true,
+// and from 5-bit groups back to 8-bit bytes with pad = false.
+func ConvertBits(data []byte, frombits, tobits uint, pad bool) ([]byte, error) {
 	acc := 0
 	bits := uint(0)
 	ret := []byte{}
 	maxv := (1 << tobits) - 1
 	maxAcc := (1 << (frombits + tobits - 1)) - 1
+	if frombits < 1 || frombits > 8 || tobits < 1 || tobits > 8 {
+		return nil, fmt.Errorf("Invalid bits %d-to-%d", frombits, tobits)
+	}
 	for _, value := range data {
+		if value>>frombits != 0 {
+			return nil, fmt.Errorf("Invalid %d-bit value %d", frombits, value)
+		}
 		acc = ((acc << frombits) | int(value)) & maxAcc
 		bits += frombits
 		for bits >= tobits {
@@ -193,7 +212,7 @@ func SegwitAddrDecode(hrp, addr string) (byte, []byte, error) {
 	if data[0] > 16 {
 		return byte(0), nil, fmt.Errorf("Invalid witness version")
 	}
-	res, err := convertbits(data[1:], 5, 8, false)
+	res, err := ConvertBits(data[1:], 5, 8, false)
 	if err != nil {
 		return byte(0), nil, err
 	}
@@ -215,7 +234,7 @@ func SegwitAddrEncode(hrp string, witver byte, witprog []byte) (string, error) {
 	if witver == 0 {
 		spec = Bech32
 	}
-	data, _ := convertbits(witprog, 8, 5, true)
+	data, _ := ConvertBits(witprog, 8, 5, true)
 	ret := Encode(hrp, append([]byte{witver}, data...), spec)
 	_, _, err := SegwitAddrDecode(hrp, ret)
 	if err != nil {
@@ -272,3 +272,90 @@ func TestInvalidAddressEnc(t *testing.T) {
 		t.Logf("OK : %v", err)
 	}
 }
+
+func TestDecodeWithLimit(t *testing.T) {
+	// 4 + 1 + 200 + 6 = 211 characters
+	data := make([]byte, 200)
+	for i := range data {
+		data[i] = byte(i % 32)
+	}
+	for _, spec := range []int{bech32m.Bech32, bech32m.Bech32m} {
+		s := bech32m.Encode("lnbc", data, spec)
+		if len(s) != 211 {
+			t.Fatalf("length %d != 211", len(s))
+		}
+		_, _, _, err := bech32m.Decode(s)
+		if err == nil {
+			t.Errorf("%d : Decode must fail over %d characters", spec, bech32m.MaxLength)
+		}
+		_, _, _, err = bech32m.DecodeWithLimit(s, len(s)-1)
+		if err == nil {
+			t.Errorf("%d : DecodeWithLimit must fail over the limit", spec)
+		}
+		for _, limit := range []int{len(s), 1023, 0} {
+			hrp, got, spec2, err := bech32m.DecodeWithLimit(strings.ToUpper(s), limit)
+			if err != nil {
+				t.Errorf("%d %d : error %v", spec, limit, err)
+				continue
+			}
+			if hrp != "lnbc" || spec2 != spec || hex.EncodeToString(got) != hex.EncodeToString(data) {
+				t.Errorf("%d %d : %s %d %x", spec, limit, hrp, spec2, got)
+			}
+		}
+		// the checksum is still verified
+		bs := []byte(s)
+		bs[100] = 'q'
+		if bs[100] == s[100] {
+			bs[100] = 'p'
+		}
+		_, _, _, err = bech32m.DecodeWithLimit(string(bs), 0)
+		if err == nil {
+			t.Errorf("%d : DecodeWithLimit must fail with the invalid checksum", spec)
+		}
+	}
+	// the default limit
+	for _, test := range validBech32 {
+		_, _, _, err := bech32m.DecodeWithLimit(test, bech32m.MaxLength)
+		if err != nil {
+			t.Errorf("%s : error %v", test, err)
+		}
+	}
+}
+
+func TestConvertBits(t *testing.T) {
+	data, _ := hex.DecodeString("00ff751e76e8199196d454941c45d1b3a323f1433bd6")
+	five, err := bech32m.ConvertBits(data, 8, 5, true)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	if len(five) != (len(data)*8+4)/5 {
+		t.Errorf("length %d", len(five))
+	}
+	for _, v := range five {
+		if v >= 32 {
+			t.Fatalf("5-bit value %d", v)
+		}
+	}
+	eight, err := bech32m.ConvertBits(five, 5, 8, false)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	if hex.EncodeToString(eight) != hex.EncodeToString(data) {
+		t.Errorf("%x != %x", eight, data)
+	}
+	// a 5-bit group is 5 padding bits
+	_, err = bech32m.ConvertBits([]byte{0}, 5, 8, false)
+	if err == nil {
+		t.Errorf("more than 4 padding bits : ConvertBits must fail")
+	}
+	// non-zero padding
+	_, err = bech32m.ConvertBits([]byte{0, 1}, 5, 8, false)
+	if err == nil {
+		t.Errorf("non-zero padding : ConvertBits must fail")
+	}
+	// the value out of range
+	_, err = bech32m.ConvertBits([]byte{32}, 5, 8, true)
+	if err == nil {
+		t.Errorf("32 : ConvertBits must fail")
+	}
+}
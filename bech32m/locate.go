@@ -0,0 +1,213 @@
+package bech32m
+
+import (
+	"fmt"
+	"strings"
+)
+
+// The error location of Bech32/Bech32m strings like LocateErrors of Bitcoin Core.
+//
+// The checksum residue polymod(values) ^ const is the syndrome of the BCH code,
+// which is 0 for the valid strings.
+// The syndrome is linear over GF(32) in the errors,
+// so that a substitution error e at the position p changes the syndrome by e * b_p,
+// where b_p is the residue of the unit error at p.
+// Since the distance of the code is 5 for the strings of at most MaxLength characters,
+// up to two substitution errors are located uniquely by solving s = e1 * b_p1 + e2 * b_p2 .
+
+// gfMul returns a * b in GF(32) with the modulus x^5 + x^3 + 1.
+func gfMul(a, b byte) byte {
+	r := byte(0)
+	for i := 0; i < 5; i++ {
+		if (b>>uint(i))&1 == 1 {
+			r ^= a
+		}
+		a <<= 1
+		if a&32 != 0 {
+			a ^= 0x29
+		}
+	}
+	return r
+}
+
+// gfInv returns 1 / a in GF(32), a^30 = a^-1 .
+func gfInv(a byte) byte {
+	r := byte(1)
+	for i := 0; i < 30; i++ {
+		r = gfMul(r, a)
+	}
+	return r
+}
+
+// symbols returns the 6 symbols of GF(32) of the residue.
+func symbols(residue int) []byte {
+	s := make([]byte, 6)
+	for i := range s {
+		s[i] = byte(residue>>uint(5*(5-i))) & 31
+	}
+	return s
+}
+
+// scale returns e * b for the symbols b.
+func scale(e byte, b []byte) []byte {
+	r := make([]byte, len(b))
+	for i := range b {
+		r[i] = gfMul(e, b[i])
+	}
+	return r
+}
+
+// equal returns whether a = b for the symbols.
+func equal(a, b []byte) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// LocateErrors returns the encoding, the positions in the string and the corrected string
+// of up to two substitution errors of the Bech32/Bech32m string.
+// If the string is valid, the positions are empty and the corrected string is the string itself.
+// The encoding with the fewer errors is chosen, and Bech32 if the numbers are the same.
+// The errors in HRP are not located.
+// If the invalid characters are found, their positions are returned with the error.
+func LocateErrors(bechString string) (int, []int, string, error) {
+	if len(bechString) > MaxLength {
+		return Failed, nil, "", fmt.Errorf("Overall max length exceeded")
+	}
+	upper := strings.ToUpper(bechString) == bechString
+	if !upper && strings.ToLower(bechString) != bechString {
+		return Failed, nil, "", fmt.Errorf("Mixed case")
+	}
+	s := strings.ToLower(bechString)
+	pos := strings.LastIndex(s, "1")
+	if pos < 0 {
+		return Failed, nil, "", fmt.Errorf("No separator character")
+	}
+	if pos < 1 {
+		return Failed, nil, "", fmt.Errorf("Empty HRP")
+	}
+	if pos+7 > len(s) {
+		return Failed, nil, "", fmt.Errorf("Too short checksum")
+	}
+	hrp := s[0:pos]
+	for _, c := range hrp {
+		if c < 33 || c > 126 {
+			return Failed, nil, "", fmt.Errorf("HRP character out of range")
+		}
+	}
+	data := []byte{}
+	invalid := []int{}
+	for p := pos + 1; p < len(s); p++ {
+		d := strings.IndexByte(charset, s[p])
+		if d == -1 {
+			invalid = append(invalid, p)
+		}
+		data = append(data, byte(d))
+	}
+	if len(invalid) > 0 {
+		return Failed, invalid, "", fmt.Errorf("Invalid Base 32 character")
+	}
+	values := append(hrpExpand(hrp), data...)
+	residue := polymod(values)
+	// b_p is the residue of the unit error at the data position p without the initial value.
+	zero := polymod(make([]byte, len(values)))
+	offset := len(values) - len(data)
+	bs := [][]byte{}
+	for p := range data {
+		unit := make([]byte, len(values))
+		unit[offset+p] = 1
+		bs = append(bs, symbols(polymod(unit)^zero))
+	}
+	spec := Failed
+	var errors map[int]byte
+	for _, sp := range []int{Bech32, Bech32m} {
+		c := 1
+		if sp == Bech32m {
+			c = bech32mConst
+		}
+		es, ok := locate(symbols(residue^c), bs)
+		if !ok {
+			continue
+		}
+		if spec == Failed || len(es) < len(errors) {
+			spec = sp
+			errors = es
+		}
+	}
+	if spec == Failed {
+		return Failed, nil, "", fmt.Errorf("Invalid checksum, the errors are not located")
+	}
+	positions := []int{}
+	corrected := []byte(s)
+	for p := range data {
+		e, ok := errors[p]
+		if !ok {
+			continue
+		}
+		positions = append(positions, pos+1+p)
+		corrected[pos+1+p] = charset[data[p]^e]
+	}
+	ret := string(corrected)
+	if upper {
+		ret = strings.ToUpper(ret)
+	}
+	return spec, positions, ret, nil
+}
+
+// locate returns the errors for the syndrome, which maps the data positions to the error values.
+func locate(syn []byte, bs [][]byte) (map[int]byte, bool) {
+	if equal(syn, make([]byte, 6)) {
+		return map[int]byte{}, true
+	}
+	// a single error s = e * b_p
+	for p, b := range bs {
+		for k := range b {
+			if b[k] == 0 {
+				continue
+			}
+			e := gfMul(syn[k], gfInv(b[k]))
+			if e != 0 && equal(scale(e, b), syn) {
+				return map[int]byte{p: e}, true
+			}
+			break
+		}
+	}
+	// two errors s = e1 * b_p1 + e2 * b_p2
+	for p1 := 0; p1 < len(bs); p1++ {
+		for p2 := p1 + 1; p2 < len(bs); p2++ {
+			b1, b2 := bs[p1], bs[p2]
+			for j := 0; j < 6; j++ {
+				found := false
+				for k := j + 1; k < 6; k++ {
+					// det = b1_j * b2_k + b1_k * b2_j
+					det := gfMul(b1[j], b2[k]) ^ gfMul(b1[k], b2[j])
+					if det == 0 {
+						continue
+					}
+					inv := gfInv(det)
+					e1 := gfMul(gfMul(syn[j], b2[k])^gfMul(syn[k], b2[j]), inv)
+					e2 := gfMul(gfMul(b1[j], syn[k])^gfMul(b1[k], syn[j]), inv)
+					if e1 != 0 && e2 != 0 {
+						sum := scale(e1, b1)
+						e2b2 := scale(e2, b2)
+						for i := range sum {
+							sum[i] ^= e2b2[i]
+						}
+						if equal(sum, syn) {
+							return map[int]byte{p1: e1, p2: e2}, true
+						}
+					}
+					found = true
+					break
+				}
+				if found {
+					break
+				}
+			}
+		}
+	}
+	return nil, false
+}
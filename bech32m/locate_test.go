@@ -0,0 +1,116 @@
+package bech32m_test
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/tnakagawa/goref/bech32m"
+)
+
+const charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+// substitute returns the string with the substitution errors at the random positions in the data part.
+func substitute(rnd *rand.Rand, s string, n int) (string, []int) {
+	lower := strings.ToLower(s)
+	sep := strings.LastIndex(lower, "1")
+	bs := []byte(lower)
+	positions := rnd.Perm(len(s) - sep - 1)[:n]
+	for i := range positions {
+		positions[i] += sep + 1
+	}
+	// sort
+	for i := range positions {
+		for j := i + 1; j < len(positions); j++ {
+			if positions[j] < positions[i] {
+				positions[i], positions[j] = positions[j], positions[i]
+			}
+		}
+	}
+	for _, p := range positions {
+		c := bs[p]
+		for c == bs[p] {
+			c = charset[rnd.Intn(32)]
+		}
+		bs[p] = c
+	}
+	if strings.ToUpper(s) == s {
+		return strings.ToUpper(string(bs)), positions
+	}
+	return string(bs), positions
+}
+
+func TestLocateErrors(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	tests := []struct {
+		s    string
+		spec int
+	}{}
+	for _, s := range validBech32 {
+		tests = append(tests, struct {
+			s    string
+			spec int
+		}{s, bech32m.Bech32})
+	}
+	for _, s := range validBech32m {
+		tests = append(tests, struct {
+			s    string
+			spec int
+		}{s, bech32m.Bech32m})
+	}
+	for _, test := range tests {
+		// valid
+		spec, positions, corrected, err := bech32m.LocateErrors(test.s)
+		if err != nil || spec != test.spec || len(positions) != 0 || corrected != test.s {
+			t.Errorf("%s : %d %v %s %v", test.s, spec, positions, corrected, err)
+		}
+		for n := 1; n <= 2; n++ {
+			for i := 0; i < 20; i++ {
+				s, want := substitute(rnd, test.s, n)
+				spec, positions, corrected, err := bech32m.LocateErrors(s)
+				if err != nil {
+					t.Errorf("%s : error %v", s, err)
+					continue
+				}
+				if spec != test.spec || corrected != test.s || len(positions) != len(want) {
+					t.Errorf("%s : %d %v %s", s, spec, positions, corrected)
+					continue
+				}
+				for j := range want {
+					if positions[j] != want[j] {
+						t.Errorf("%s : %v != %v", s, positions, want)
+						break
+					}
+				}
+			}
+		}
+	}
+	// more than two errors are not located or corrected to another valid string
+	located := 0
+	for i := 0; i < 100; i++ {
+		s, _ := substitute(rnd, "abcdef1qpzry9x8gf2tvdw0s3jn54khce6mua7lmqqqxw", 3)
+		_, positions, corrected, err := bech32m.LocateErrors(s)
+		if err != nil {
+			continue
+		}
+		located++
+		if len(positions) > 2 {
+			t.Errorf("%s : %v", s, positions)
+		}
+		if _, _, _, err := bech32m.Decode(corrected); err != nil {
+			t.Errorf("%s : the corrected string is invalid : %v", corrected, err)
+		}
+	}
+	t.Logf("3 errors : %d / 100 are located", located)
+	// the invalid characters
+	_, positions, _, err := bech32m.LocateErrors("abcdef1qpzry9x8gf2tbdw0s3jn54khce6mua7lmqqqxw")
+	if err == nil || len(positions) != 1 || positions[0] != 19 {
+		t.Errorf("invalid character : %v %v", positions, err)
+	}
+	for _, s := range []string{"A1lqfn3a", "a1lqfn", "1qqqqqqqq", "x"} {
+		_, _, _, err := bech32m.LocateErrors(s)
+		if err == nil {
+			t.Errorf("%s : LocateErrors must fail", s)
+		}
+	}
+}
@@ -0,0 +1,661 @@
+// Package bolt11 is the Lightning invoice of BOLT #11.
+//
+// BOLT #11: Invoice Protocol for Lightning Payments
+// https://github.com/lightning/bolts/blob/master/11-payment-encoding.md
+//
+//	invoice      = hrp || "1" || data || checksum, Bech32 without the length limit
+//	hrp          = "ln" || currency || [amount || [multiplier]]
+//	data         = timestamp (35 bits) || tagged fields || signature (520 bits)
+//	tagged field = type (5 bits) || data_length (10 bits) || data_length * 5 bits
+//	signature    = r (32 bytes) || s (32 bytes) || recovery id (1 byte)
+//	               of SHA256(hrp || data before the signature, padded with 0 bits to bytes)
+//
+// The payee node is recovered from the signature, unless it is in the n field.
+package bolt11
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"math/big"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/tnakagawa/goref/bech32m"
+	"github.com/tnakagawa/goref/ec"
+	"github.com/tnakagawa/goref/ecdsa"
+)
+
+// The types of the tagged fields.
+const (
+	typeP = 1  // payment hash
+	typeR = 3  // routing hints
+	type9 = 5  // features
+	typeX = 6  // expiry
+	typeF = 9  // fallback address
+	typeD = 13 // description
+	typeS = 16 // payment secret
+	typeN = 19 // payee node
+	typeH = 23 // description hash
+	typeC = 24 // min_final_cltv_expiry_delta
+	typeM = 27 // payment metadata
+)
+
+const (
+	// DefaultExpiry is the expiry in seconds if the x field is not specified.
+	DefaultExpiry = 3600
+	// DefaultMinFinalCLTVExpiry is the min_final_cltv_expiry_delta if the c field is not specified.
+	DefaultMinFinalCLTVExpiry = 18
+	// timestampLen is the number of the 5-bit groups of the timestamp.
+	timestampLen = 7
+	// signatureLen is the number of the 5-bit groups of the signature.
+	signatureLen = 104
+	// hopLen is the size of a hop in the r field.
+	hopLen = 51
+	// maxFieldLen is the maximum data_length of the 10 bits.
+	maxFieldLen = 1023
+)
+
+// network is the prefixes of the addresses of a currency.
+type network struct {
+	hrp   string // the HRP of the segwit addresses
+	p2pkh byte   // the version of the base58 P2PKH addresses
+	p2sh  byte   // the version of the base58 P2SH addresses
+}
+
+// networks maps the currency prefixes of the invoices to the networks,
+// where "tbs" is signet which shares the addresses with testnet.
+var networks = map[string]network{
+	"bc":   {"bc", 0x00, 0x05},
+	"tb":   {"tb", 0x6f, 0xc4},
+	"tbs":  {"tb", 0x6f, 0xc4},
+	"bcrt": {"bcrt", 0x6f, 0xc4},
+	"sb":   {"sb", 0x3f, 0x7b},
+}
+
+// multipliers are the units of the amount multipliers in 0.1 millisatoshi, in the order of the preference.
+var multipliers = []struct {
+	suffix string
+	unit   *big.Int
+}{
+	{"", big.NewInt(1000000000000)}, // 1 BTC = 10^11 millisatoshi
+	{"m", big.NewInt(1000000000)},
+	{"u", big.NewInt(1000000)},
+	{"n", big.NewInt(1000)},
+	{"p", big.NewInt(1)},
+}
+
+// Invoice is the decoded Lightning invoice.
+type Invoice struct {
+	Currency           string      // the currency prefix, "bc", "tb", "tbs", "bcrt" or "sb"
+	MilliSat           uint64      // the amount in millisatoshi, 0 if any amount
+	Timestamp          int64       // the seconds since 1970, less than 2^35
+	PaymentHash        []byte      // p, 32 bytes
+	PaymentSecret      []byte      // s, 32 bytes
+	Description        string      // d, which is written unless DescriptionHash is set
+	DescriptionHash    []byte      // h, SHA256 of the description, 32 bytes or nil
+	Metadata           []byte      // m, or nil
+	Payee              *ec.Point   // the payee node, from the n field or recovered from the signature
+	PayeeField         bool        // whether the payee node is in the n field
+	Expiry             uint64      // x, the seconds, 0 if not specified, see DefaultExpiry
+	MinFinalCLTVExpiry uint64      // c, 0 if not specified, see DefaultMinFinalCLTVExpiry
+	Fallbacks          []Fallback  // f, the on-chain addresses
+	RouteHints         [][]HopHint // r, each is a private route to the payee
+	Features           *big.Int    // 9, the bit i is the feature bit i, nil if not specified
+	Extra              []Field     // the fields which are not interpreted, unknown or skipped
+	Signature          []byte      // r || s || recovery id, set by Decode and Sign
+}
+
+// Fallback is the on-chain fallback address.
+type Fallback struct {
+	Version byte   // the witness version 0-16, 17 for P2PKH or 18 for P2SH
+	Program []byte // the witness program, or the hash of P2PKH or P2SH
+}
+
+// HopHint is a hop of the routing hints.
+type HopHint struct {
+	NodeID                    *ec.Point // the public key of the node
+	ShortChannelID            uint64    // the short channel id of the outgoing channel
+	FeeBaseMsat               uint32    // the base fee in millisatoshi
+	FeeProportionalMillionths uint32    // the proportional fee in millionths
+	CLTVExpiryDelta           uint16    // the cltv_expiry_delta of the channel
+}
+
+// Field is the tagged field as it is.
+type Field struct {
+	Type byte   // the 5-bit type
+	Data []byte // the 5-bit groups
+}
+
+// Decode returns the invoice and verifies the signature.
+// The payee node is recovered from the signature unless the n field is specified.
+// The unknown fields, the duplicated fields and the p, h, s and n fields of the wrong lengths are skipped to Extra.
+func Decode(invoice string) (*Invoice, error) {
+	// the invoices are longer than the segwit addresses
+	hrp, data, spec, err := bech32m.DecodeWithLimit(invoice, 0)
+	if err != nil {
+		return nil, err
+	}
+	if spec != bech32m.Bech32 {
+		return nil, fmt.Errorf("the checksum is not Bech32")
+	}
+	inv := &Invoice{}
+	inv.Currency, inv.MilliSat, err = parseHRP(hrp)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < timestampLen+signatureLen {
+		return nil, fmt.Errorf("too short data")
+	}
+	sig := data[len(data)-signatureLen:]
+	data = data[:len(data)-signatureLen]
+	inv.Timestamp = int64(toUint(data[:timestampLen]))
+	err = inv.parseFields(data[timestampLen:])
+	if err != nil {
+		return nil, err
+	}
+	// r (32 bytes) || s (32 bytes) || recovery id (1 byte)
+	bs, err := bech32m.ConvertBits(sig, 5, 8, false)
+	if err != nil {
+		return nil, err
+	}
+	hash := sigHash(hrp, data)
+	r := new(big.Int).SetBytes(bs[:32])
+	s := new(big.Int).SetBytes(bs[32:64])
+	if inv.PayeeField {
+		if !ecdsa.VerifyHash(inv.Payee, hash, r, s) {
+			return nil, fmt.Errorf("invalid signature")
+		}
+	} else {
+		inv.Payee, err = ecdsa.RecoverPubKey(hash, r, s, bs[64])
+		if err != nil {
+			return nil, err
+		}
+	}
+	inv.Signature = bs
+	return inv, nil
+}
+
+// parseHRP returns the currency and the amount in millisatoshi of HRP.
+func parseHRP(hrp string) (string, uint64, error) {
+	if !strings.HasPrefix(hrp, "ln") {
+		return "", 0, fmt.Errorf("the prefix is not ln : %q", hrp)
+	}
+	currency := hrp[2:]
+	amount := ""
+	i := strings.IndexAny(currency, "0123456789")
+	if i >= 0 {
+		currency, amount = currency[:i], currency[i:]
+	}
+	if _, ok := networks[currency]; !ok {
+		return "", 0, fmt.Errorf("unknown currency : %q", currency)
+	}
+	if amount == "" {
+		return currency, 0, nil
+	}
+	msat, err := parseAmount(amount)
+	if err != nil {
+		return "", 0, err
+	}
+	return currency, msat, nil
+}
+
+// parseAmount returns the amount in millisatoshi of the digits and the optional multiplier.
+func parseAmount(amount string) (uint64, error) {
+	digits := amount
+	unit := multipliers[0].unit
+	for _, m := range multipliers[1:] {
+		if strings.HasSuffix(amount, m.suffix) {
+			digits = amount[:len(amount)-1]
+			unit = m.unit
+			break
+		}
+	}
+	if digits == "" || strings.Trim(digits, "0123456789") != "" {
+		return 0, fmt.Errorf("illegal amount : %q", amount)
+	}
+	v, _ := new(big.Int).SetString(digits, 10)
+	// the amount in 0.1 millisatoshi must be a multiple of 10
+	v.Mul(v, unit)
+	msat, r := new(big.Int).QuoRem(v, big.NewInt(10), new(big.Int))
+	if r.Sign() != 0 {
+		return 0, fmt.Errorf("the amount is not a multiple of millisatoshi : %q", amount)
+	}
+	if msat.Sign() == 0 || !msat.IsUint64() {
+		return 0, fmt.Errorf("the amount is out of range : %q", amount)
+	}
+	return msat.Uint64(), nil
+}
+
+// formatAmount returns the shortest amount and multiplier of the millisatoshi.
+func formatAmount(msat uint64) string {
+	v := new(big.Int).Mul(new(big.Int).SetUint64(msat), big.NewInt(10))
+	ret := ""
+	for _, m := range multipliers {
+		q, r := new(big.Int).QuoRem(v, m.unit, new(big.Int))
+		if r.Sign() != 0 {
+			continue
+		}
+		s := q.String() + m.suffix
+		if ret == "" || len(s) < len(ret) {
+			ret = s
+		}
+	}
+	return ret
+}
+
+// toUint returns the big-endian integer of the 5-bit groups.
+func toUint(groups []byte) uint64 {
+	v := uint64(0)
+	for _, g := range groups {
+		v = v<<5 | uint64(g)
+	}
+	return v
+}
+
+// fromUint returns the big-endian 5-bit groups of the integer, which are at least size groups.
+func fromUint(v uint64, size int) []byte {
+	groups := []byte{}
+	for v > 0 || len(groups) < size {
+		groups = append([]byte{byte(v & 31)}, groups...)
+		v >>= 5
+	}
+	return groups
+}
+
+// toBytes returns the bytes of the 5-bit groups, where the padding bits must be 0.
+func toBytes(groups []byte) ([]byte, error) {
+	return bech32m.ConvertBits(groups, 5, 8, false)
+}
+
+// toGroups returns the 5-bit groups of the bytes, padded with 0 bits.
+func toGroups(bs []byte) []byte {
+	groups, _ := bech32m.ConvertBits(bs, 8, 5, true)
+	return groups
+}
+
+// decodePoint returns the point of the compressed public key, which must be on the curve.
+func decodePoint(bs []byte) (*ec.Point, error) {
+	P, err := ec.Decode(bs)
+	if err != nil {
+		return nil, err
+	}
+	if len(bs) != 33 || !P.OnCurve() {
+		return nil, fmt.Errorf("invalid public key : %x", bs)
+	}
+	return P, nil
+}
+
+// parseFields sets the tagged fields to the invoice.
+func (inv *Invoice) parseFields(data []byte) error {
+	seen := map[byte]bool{}
+	for len(data) > 0 {
+		if len(data) < 3 {
+			return fmt.Errorf("broken tagged field")
+		}
+		typ := data[0]
+		l := int(toUint(data[1:3]))
+		if len(data) < 3+l {
+			return fmt.Errorf("too short tagged field : %d < %d", len(data)-3, l)
+		}
+		field := Field{Type: typ, Data: data[3 : 3+l]}
+		data = data[3+l:]
+		// r and f may be repeated
+		if typ != typeR && typ != typeF && seen[typ] {
+			inv.Extra = append(inv.Extra, field)
+			continue
+		}
+		ok, err := inv.parseField(field)
+		if err != nil {
+			return fmt.Errorf("field %d : %v", typ, err)
+		}
+		if !ok {
+			inv.Extra = append(inv.Extra, field)
+			continue
+		}
+		seen[typ] = true
+	}
+	if !seen[typeP] {
+		return fmt.Errorf("missing payment hash")
+	}
+	if !seen[typeS] {
+		return fmt.Errorf("missing payment secret")
+	}
+	if seen[typeD] && seen[typeH] {
+		return fmt.Errorf("both description and description hash")
+	}
+	if !seen[typeD] && !seen[typeH] {
+		return fmt.Errorf("missing description and description hash")
+	}
+	return nil
+}
+
+// parseField sets the field to the invoice, it returns false if the field is skipped.
+func (inv *Invoice) parseField(field Field) (bool, error) {
+	var err error
+	d := field.Data
+	switch field.Type {
+	case typeP, typeS, typeH:
+		// 256 bits in 52 groups
+		if len(d) != 52 {
+			return false, nil
+		}
+		bs, err := toBytes(d)
+		if err != nil {
+			return false, err
+		}
+		switch field.Type {
+		case typeP:
+			inv.PaymentHash = bs
+		case typeS:
+			inv.PaymentSecret = bs
+		default:
+			inv.DescriptionHash = bs
+		}
+	case typeN:
+		// 264 bits in 53 groups
+		if len(d) != 53 {
+			return false, nil
+		}
+		bs, err := toBytes(d)
+		if err != nil {
+			return false, err
+		}
+		P, err := decodePoint(bs)
+		if err != nil {
+			return false, nil
+		}
+		inv.Payee = P
+		inv.PayeeField = true
+	case typeD:
+		bs, err := toBytes(d)
+		if err != nil {
+			return false, err
+		}
+		if !utf8.Valid(bs) {
+			return false, fmt.Errorf("the description is not UTF-8")
+		}
+		inv.Description = string(bs)
+	case typeM:
+		inv.Metadata, err = toBytes(d)
+		if err != nil {
+			return false, err
+		}
+	case typeX, typeC:
+		if len(d) > 12 {
+			return false, fmt.Errorf("too long integer : %d", len(d))
+		}
+		if field.Type == typeX {
+			inv.Expiry = toUint(d)
+		} else {
+			inv.MinFinalCLTVExpiry = toUint(d)
+		}
+	case typeF:
+		if len(d) < 1 {
+			return false, fmt.Errorf("empty fallback address")
+		}
+		// the unknown versions are skipped
+		if d[0] > 18 {
+			return false, nil
+		}
+		program, err := toBytes(d[1:])
+		if err != nil {
+			return false, err
+		}
+		f := Fallback{Version: d[0], Program: program}
+		err = f.validate()
+		if err != nil {
+			return false, err
+		}
+		inv.Fallbacks = append(inv.Fallbacks, f)
+	case typeR:
+		bs, err := toBytes(d)
+		if err != nil {
+			return false, err
+		}
+		if len(bs) == 0 || len(bs)%hopLen != 0 {
+			return false, fmt.Errorf("the routing hints are not a multiple of %d bytes : %d", hopLen, len(bs))
+		}
+		hops := []HopHint{}
+		for ; len(bs) > 0; bs = bs[hopLen:] {
+			P, err := decodePoint(bs[:33])
+			if err != nil {
+				return false, err
+			}
+			hops = append(hops, HopHint{
+				NodeID:                    P,
+				ShortChannelID:            toUint64(bs[33:41]),
+				FeeBaseMsat:               uint32(toUint64(bs[41:45])),
+				FeeProportionalMillionths: uint32(toUint64(bs[45:49])),
+				CLTVExpiryDelta:           uint16(toUint64(bs[49:51])),
+			})
+		}
+		inv.RouteHints = append(inv.RouteHints, hops)
+	case type9:
+		f := new(big.Int)
+		for _, g := range d {
+			f.Lsh(f, 5).Or(f, big.NewInt(int64(g)))
+		}
+		inv.Features = f
+	default:
+		return false, nil
+	}
+	return true, nil
+}
+
+// toUint64 returns the big-endian integer of the bytes.
+func toUint64(bs []byte) uint64 {
+	v := uint64(0)
+	for _, b := range bs {
+		v = v<<8 | uint64(b)
+	}
+	return v
+}
+
+// fromUint64 returns the big-endian bytes of the integer in size bytes.
+func fromUint64(v uint64, size int) []byte {
+	bs := make([]byte, size)
+	for i := size - 1; i >= 0; i-- {
+		bs[i] = byte(v)
+		v >>= 8
+	}
+	return bs
+}
+
+// sigHash returns the hash of the signature, SHA256(hrp || data in bytes).
+func sigHash(hrp string, data []byte) []byte {
+	h := sha256.New()
+	bs, _ := bech32m.ConvertBits(data, 5, 8, true)
+	h.Write([]byte(hrp))
+	h.Write(bs)
+	return h.Sum(nil)
+}
+
+// Sign returns the invoice signed by the private key x of the payee node.
+// Payee and Signature are set by the key and the signature,
+// and the payee node is written in the n field if PayeeField is true.
+// The fields are written in the order p, d or h, m, c, x, f, r, n, s, 9 and Extra.
+func (inv *Invoice) Sign(x *big.Int) (string, error) {
+	hrp, err := inv.hrp()
+	if err != nil {
+		return "", err
+	}
+	payee := ec.Mul(x, ec.G)
+	if payee.Infinite() {
+		return "", fmt.Errorf("illegal private key")
+	}
+	data, err := inv.data(payee)
+	if err != nil {
+		return "", err
+	}
+	r, s, v := ecdsa.SignRecoverable(sigHash(hrp, data), x)
+	sig := append(append(fromBig(r, 32), fromBig(s, 32)...), v)
+	inv.Payee = payee
+	inv.Signature = sig
+	return bech32m.Encode(hrp, append(data, toGroups(sig)...), bech32m.Bech32), nil
+}
+
+// fromBig returns the big-endian bytes of the integer in size bytes.
+func fromBig(v *big.Int, size int) []byte {
+	bs := make([]byte, size)
+	vs := v.Bytes()
+	copy(bs[size-len(vs):], vs)
+	return bs
+}
+
+// hrp returns HRP of the invoice.
+func (inv *Invoice) hrp() (string, error) {
+	if _, ok := networks[inv.Currency]; !ok {
+		return "", fmt.Errorf("unknown currency : %q", inv.Currency)
+	}
+	hrp := "ln" + inv.Currency
+	if inv.MilliSat > 0 {
+		hrp += formatAmount(inv.MilliSat)
+	}
+	return hrp, nil
+}
+
+// data returns the 5-bit groups of the timestamp and the tagged fields of the payee node.
+func (inv *Invoice) data(payee *ec.Point) ([]byte, error) {
+	if inv.Timestamp < 0 || inv.Timestamp >= 1<<35 {
+		return nil, fmt.Errorf("the timestamp is out of range : %d", inv.Timestamp)
+	}
+	if len(inv.PaymentHash) != 32 {
+		return nil, fmt.Errorf("illegal payment hash size : %d", len(inv.PaymentHash))
+	}
+	if len(inv.PaymentSecret) != 32 {
+		return nil, fmt.Errorf("illegal payment secret size : %d", len(inv.PaymentSecret))
+	}
+	if inv.DescriptionHash != nil && len(inv.DescriptionHash) != 32 {
+		return nil, fmt.Errorf("illegal description hash size : %d", len(inv.DescriptionHash))
+	}
+	if inv.DescriptionHash != nil && inv.Description != "" {
+		return nil, fmt.Errorf("both description and description hash")
+	}
+	fields := []Field{{typeP, toGroups(inv.PaymentHash)}}
+	if inv.DescriptionHash == nil {
+		fields = append(fields, Field{typeD, toGroups([]byte(inv.Description))})
+	} else {
+		fields = append(fields, Field{typeH, toGroups(inv.DescriptionHash)})
+	}
+	if inv.Metadata != nil {
+		fields = append(fields, Field{typeM, toGroups(inv.Metadata)})
+	}
+	if inv.MinFinalCLTVExpiry > 0 {
+		fields = append(fields, Field{typeC, fromUint(inv.MinFinalCLTVExpiry, 0)})
+	}
+	if inv.Expiry > 0 {
+		fields = append(fields, Field{typeX, fromUint(inv.Expiry, 0)})
+	}
+	for _, f := range inv.Fallbacks {
+		err := f.validate()
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, Field{typeF, append([]byte{f.Version}, toGroups(f.Program)...)})
+	}
+	for i, hops := range inv.RouteHints {
+		if len(hops) == 0 {
+			return nil, fmt.Errorf("%d : empty routing hints", i)
+		}
+		bs := []byte{}
+		for _, hop := range hops {
+			if hop.NodeID == nil || !hop.NodeID.OnCurve() {
+				return nil, fmt.Errorf("%d : invalid node id", i)
+			}
+			bs = append(bs, hop.NodeID.Compressed()...)
+			bs = append(bs, fromUint64(hop.ShortChannelID, 8)...)
+			bs = append(bs, fromUint64(uint64(hop.FeeBaseMsat), 4)...)
+			bs = append(bs, fromUint64(uint64(hop.FeeProportionalMillionths), 4)...)
+			bs = append(bs, fromUint64(uint64(hop.CLTVExpiryDelta), 2)...)
+		}
+		fields = append(fields, Field{typeR, toGroups(bs)})
+	}
+	if inv.PayeeField {
+		fields = append(fields, Field{typeN, toGroups(payee.Compressed())})
+	}
+	fields = append(fields, Field{typeS, toGroups(inv.PaymentSecret)})
+	if inv.Features != nil && inv.Features.Sign() > 0 {
+		groups := []byte{}
+		for f := new(big.Int).Set(inv.Features); f.Sign() > 0; f.Rsh(f, 5) {
+			groups = append([]byte{byte(f.Uint64() & 31)}, groups...)
+		}
+		fields = append(fields, Field{type9, groups})
+	}
+	fields = append(fields, inv.Extra...)
+	data := fromUint(uint64(inv.Timestamp), timestampLen)
+	for _, field := range fields {
+		if field.Type > 31 || len(field.Data) > maxFieldLen {
+			return nil, fmt.Errorf("field %d : too long data : %d", field.Type, len(field.Data))
+		}
+		data = append(data, field.Type)
+		data = append(data, fromUint(uint64(len(field.Data)), 2)...)
+		data = append(data, field.Data...)
+	}
+	return data, nil
+}
+
+// validate checks the length of the program for the version.
+func (f *Fallback) validate() error {
+	switch {
+	case f.Version == 0:
+		if len(f.Program) != 20 && len(f.Program) != 32 {
+			return fmt.Errorf("illegal witness program size for version 0 : %d", len(f.Program))
+		}
+	case f.Version <= 16:
+		if len(f.Program) < 2 || len(f.Program) > 40 {
+			return fmt.Errorf("illegal witness program size : %d", len(f.Program))
+		}
+	case f.Version <= 18:
+		if len(f.Program) != 20 {
+			return fmt.Errorf("illegal hash size : %d", len(f.Program))
+		}
+	default:
+		return fmt.Errorf("unknown version : %d", f.Version)
+	}
+	return nil
+}
+
+// Address returns the address of the fallback for the currency of the invoice,
+// the segwit address for the witness versions, or the base58 address for P2PKH and P2SH.
+func (f *Fallback) Address(currency string) (string, error) {
+	nw, ok := networks[currency]
+	if !ok {
+		return "", fmt.Errorf("unknown currency : %q", currency)
+	}
+	err := f.validate()
+	if err != nil {
+		return "", err
+	}
+	switch f.Version {
+	case 17:
+		return base58Check(nw.p2pkh, f.Program), nil
+	case 18:
+		return base58Check(nw.p2sh, f.Program), nil
+	}
+	return bech32m.SegwitAddrEncode(nw.hrp, f.Version, f.Program)
+}
+
+// base58Check returns the Base58Check encoding of the version and the payload,
+// version || payload || the first 4 bytes of SHA256(SHA256(version || payload)).
+func base58Check(version byte, payload []byte) string {
+	bs := append([]byte{version}, payload...)
+	h := sha256.Sum256(bs)
+	h = sha256.Sum256(h[:])
+	bs = append(bs, h[:4]...)
+	alphabet := "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+	s := []byte{}
+	x := new(big.Int).SetBytes(bs)
+	m := new(big.Int)
+	for x.Sign() > 0 {
+		x.QuoRem(x, big.NewInt(58), m)
+		s = append([]byte{alphabet[m.Int64()]}, s...)
+	}
+	// the leading zero bytes are '1'
+	for _, b := range bs {
+		if b != 0 {
+			break
+		}
+		s = append([]byte{'1'}, s...)
+	}
+	return string(s)
+}
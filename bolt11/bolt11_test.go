@@ -0,0 +1,317 @@
+package bolt11_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/tnakagawa/goref/bech32m"
+	"github.com/tnakagawa/goref/bolt11"
+	"github.com/tnakagawa/goref/ec"
+)
+
+type Bolt11Test struct {
+	Title      string    `json:"title"`
+	URL        string    `json:"url"`
+	PrivateKey string    `json:"private_key"`
+	Valid      []Vector  `json:"valid"`
+	Invalid    []Invalid `json:"invalid"`
+}
+
+type Vector struct {
+	Comment            string      `json:"comment"`
+	Invoice            string      `json:"invoice"`
+	Currency           string      `json:"currency"`
+	MilliSat           uint64      `json:"msat"`
+	Timestamp          int64       `json:"timestamp"`
+	PaymentHash        string      `json:"payment_hash"`
+	PaymentSecret      string      `json:"payment_secret"`
+	Description        string      `json:"description"`
+	DescriptionHash    string      `json:"description_hash"`
+	Metadata           string      `json:"metadata"`
+	Payee              string      `json:"payee"`
+	PayeeField         bool        `json:"payee_field"`
+	Expiry             uint64      `json:"expiry"`
+	MinFinalCLTVExpiry uint64      `json:"min_final_cltv_expiry"`
+	Fallbacks          []string    `json:"fallbacks"`
+	RouteHints         [][]HopHint `json:"route_hints"`
+	Features           []int       `json:"features"`
+	Extra              int         `json:"extra"`
+	Reencode           bool        `json:"reencode"`
+}
+
+type HopHint struct {
+	NodeID                    string `json:"node_id"`
+	ShortChannelID            string `json:"short_channel_id"`
+	FeeBaseMsat               uint32 `json:"fee_base_msat"`
+	FeeProportionalMillionths uint32 `json:"fee_proportional_millionths"`
+	CLTVExpiryDelta           uint16 `json:"cltv_expiry_delta"`
+}
+
+type Invalid struct {
+	Comment string `json:"comment"`
+	Invoice string `json:"invoice"`
+	Error   string `json:"error"`
+}
+
+// hexBytes returns the bytes of the hexstring, nil if it is empty.
+func hexBytes(s string) []byte {
+	if s == "" {
+		return nil
+	}
+	bs, _ := hex.DecodeString(s)
+	return bs
+}
+
+// check returns the name of the first field which does not match the vector.
+func check(v *Vector, inv *bolt11.Invoice) string {
+	if inv.Currency != v.Currency {
+		return "currency"
+	}
+	if inv.MilliSat != v.MilliSat {
+		return "msat"
+	}
+	if inv.Timestamp != v.Timestamp {
+		return "timestamp"
+	}
+	if !bytes.Equal(inv.PaymentHash, hexBytes(v.PaymentHash)) {
+		return "payment_hash"
+	}
+	if !bytes.Equal(inv.PaymentSecret, hexBytes(v.PaymentSecret)) {
+		return "payment_secret"
+	}
+	if inv.Description != v.Description {
+		return "description"
+	}
+	if !bytes.Equal(inv.DescriptionHash, hexBytes(v.DescriptionHash)) {
+		return "description_hash"
+	}
+	if !bytes.Equal(inv.Metadata, hexBytes(v.Metadata)) {
+		return "metadata"
+	}
+	if hex.EncodeToString(inv.Payee.Compressed()) != v.Payee || inv.PayeeField != v.PayeeField {
+		return "payee"
+	}
+	if inv.Expiry != v.Expiry {
+		return "expiry"
+	}
+	if inv.MinFinalCLTVExpiry != v.MinFinalCLTVExpiry {
+		return "min_final_cltv_expiry"
+	}
+	if len(inv.Fallbacks) != len(v.Fallbacks) {
+		return "fallbacks"
+	}
+	for i, f := range inv.Fallbacks {
+		addr, err := f.Address(inv.Currency)
+		if err != nil || addr != v.Fallbacks[i] {
+			return "fallbacks"
+		}
+	}
+	if len(inv.RouteHints) != len(v.RouteHints) {
+		return "route_hints"
+	}
+	for i, hops := range inv.RouteHints {
+		if len(hops) != len(v.RouteHints[i]) {
+			return "route_hints"
+		}
+		for j, hop := range hops {
+			w := v.RouteHints[i][j]
+			scid := hex.EncodeToString(new(big.Int).SetUint64(hop.ShortChannelID).FillBytes(make([]byte, 8)))
+			if hex.EncodeToString(hop.NodeID.Compressed()) != w.NodeID || scid != w.ShortChannelID ||
+				hop.FeeBaseMsat != w.FeeBaseMsat || hop.FeeProportionalMillionths != w.FeeProportionalMillionths ||
+				hop.CLTVExpiryDelta != w.CLTVExpiryDelta {
+				return "route_hints"
+			}
+		}
+	}
+	features := new(big.Int)
+	for _, bit := range v.Features {
+		features.SetBit(features, bit, 1)
+	}
+	if (inv.Features == nil) != (v.Features == nil) || inv.Features != nil && inv.Features.Cmp(features) != 0 {
+		return "features"
+	}
+	if len(inv.Extra) != v.Extra {
+		return "extra"
+	}
+	return ""
+}
+
+func TestVectors(t *testing.T) {
+	bs, err := ioutil.ReadFile("./bolt11test.json")
+	if err != nil {
+		t.Errorf("%v", err)
+		return
+	}
+	var test Bolt11Test
+	err = json.Unmarshal(bs, &test)
+	if err != nil {
+		t.Errorf("%v", err)
+		return
+	}
+	x := new(big.Int).SetBytes(hexBytes(test.PrivateKey))
+	for _, v := range test.Valid {
+		inv, err := bolt11.Decode(v.Invoice)
+		if err != nil {
+			t.Errorf("%s : %v", v.Comment, err)
+			continue
+		}
+		if name := check(&v, inv); name != "" {
+			t.Errorf("%s : not match %s", v.Comment, name)
+			continue
+		}
+		// the upper case is the same invoice
+		upper, err := bolt11.Decode(strings.ToUpper(v.Invoice))
+		if err != nil || check(&v, upper) != "" {
+			t.Errorf("%s : upper case %v", v.Comment, err)
+			continue
+		}
+		if !v.Reencode {
+			continue
+		}
+		// the deterministic signature of RFC6979 reproduces the invoice
+		s, err := inv.Sign(x)
+		if err != nil {
+			t.Errorf("%s : %v", v.Comment, err)
+			continue
+		}
+		if s != v.Invoice {
+			t.Errorf("%s : not match\n%s\n%s", v.Comment, s, v.Invoice)
+		}
+	}
+	for _, v := range test.Invalid {
+		_, err := bolt11.Decode(v.Invoice)
+		if err == nil {
+			t.Errorf("%s : no error", v.Comment)
+			continue
+		}
+		// the invoice fails for the reason of the comment
+		if !strings.Contains(err.Error(), v.Error) {
+			t.Errorf("%s : not match %q %q", v.Comment, err.Error(), v.Error)
+		}
+	}
+}
+
+func TestSign(t *testing.T) {
+	x, _ := new(big.Int).SetString("c28a9f80738f770d527803a566cf6fc3edf6cea586c4fc4a5223a5ad797e1ac3", 16)
+	hop, _ := ec.DecodeString("029e03a901b85534ff1e92c43c74431f7ce72046060fcf7a95c37e148f78c77255")
+	inv := &bolt11.Invoice{
+		Currency:           "tbs",
+		MilliSat:           1,
+		Timestamp:          1700000000,
+		PaymentHash:        bytes.Repeat([]byte{0x01}, 32),
+		PaymentSecret:      bytes.Repeat([]byte{0x02}, 32),
+		Description:        "signet",
+		Metadata:           []byte{0xff},
+		PayeeField:         true,
+		Expiry:             86400,
+		MinFinalCLTVExpiry: 40,
+		Fallbacks: []bolt11.Fallback{
+			{Version: 1, Program: bytes.Repeat([]byte{0x03}, 32)},
+			{Version: 17, Program: bytes.Repeat([]byte{0x04}, 20)},
+		},
+		RouteHints: [][]bolt11.HopHint{
+			{{NodeID: hop, ShortChannelID: 1 << 40, FeeBaseMsat: 1000, FeeProportionalMillionths: 1, CLTVExpiryDelta: 144}},
+		},
+		Features: new(big.Int).SetBit(new(big.Int), 14, 1),
+		Extra:    []bolt11.Field{{Type: 31, Data: []byte{1, 2, 3}}},
+	}
+	s, err := inv.Sign(x)
+	if err != nil {
+		t.Errorf("%v", err)
+		return
+	}
+	// 1 msat is 10 pico BTC
+	if !strings.HasPrefix(s, "lntbs10p1") {
+		t.Errorf("illegal prefix %s", s)
+		return
+	}
+	dec, err := bolt11.Decode(s)
+	if err != nil {
+		t.Errorf("%v", err)
+		return
+	}
+	if !bytes.Equal(dec.Payee.Compressed(), ec.Mul(x, ec.G).Compressed()) || !bytes.Equal(dec.Signature, inv.Signature) {
+		t.Errorf("not match payee or signature")
+		return
+	}
+	// the decoded invoice reproduces the invoice
+	s2, err := dec.Sign(x)
+	if err != nil || s2 != s {
+		t.Errorf("not match %v\n%s\n%s", err, s2, s)
+		return
+	}
+	// the fallback addresses
+	for i, want := range []string{"tb1pqvpsxqcrqvpsxqcrqvpsxqcrqvpsxqcrqvpsxqcrqvpsxqcrqvpsm733uu", "mftBoqSx2VtqL1xVWzTtELD7qkGqS32asd"} {
+		addr, err := dec.Fallbacks[i].Address(dec.Currency)
+		if err != nil || addr != want {
+			t.Errorf("not match %v %s %s", err, addr, want)
+		}
+	}
+	if dec.Features.Bit(14) != 1 || len(dec.Extra) != 1 || !bytes.Equal(dec.Extra[0].Data, []byte{1, 2, 3}) {
+		t.Errorf("not match features or extra")
+	}
+	// the illegal invoices are not signed
+	for _, f := range []func(*bolt11.Invoice){
+		func(inv *bolt11.Invoice) { inv.Currency = "xx" },
+		func(inv *bolt11.Invoice) { inv.Timestamp = 1 << 35 },
+		func(inv *bolt11.Invoice) { inv.PaymentHash = nil },
+		func(inv *bolt11.Invoice) { inv.PaymentSecret = nil },
+		func(inv *bolt11.Invoice) { inv.DescriptionHash = make([]byte, 32) },
+		func(inv *bolt11.Invoice) { inv.Fallbacks = []bolt11.Fallback{{Version: 0, Program: make([]byte, 21)}} },
+		func(inv *bolt11.Invoice) { inv.RouteHints = [][]bolt11.HopHint{{}} },
+		func(inv *bolt11.Invoice) { inv.Description = strings.Repeat("a", 640) },
+	} {
+		tmp := *dec
+		f(&tmp)
+		_, err := tmp.Sign(x)
+		if err == nil {
+			t.Errorf("no error")
+		}
+	}
+}
+
+func TestTampered(t *testing.T) {
+	x, _ := new(big.Int).SetString("e126f68f7eafcc8b74f54d269fe206be715000f94dac067d1c04a8ca3b2db734", 16)
+	inv := &bolt11.Invoice{Currency: "bc", MilliSat: 2500000000, Timestamp: 1496314658, PaymentHash: make([]byte, 32), PaymentSecret: make([]byte, 32)}
+	for _, payeeField := range []bool{false, true} {
+		inv.PayeeField = payeeField
+		s, err := inv.Sign(x)
+		if err != nil {
+			t.Errorf("%v", err)
+			return
+		}
+		hrp, data, _, err := bech32m.DecodeWithLimit(s, 0)
+		if err != nil {
+			t.Errorf("%v", err)
+			return
+		}
+		// the Bech32m checksum
+		_, err = bolt11.Decode(bech32m.Encode(hrp, append([]byte{}, data...), bech32m.Bech32m))
+		if err == nil {
+			t.Errorf("no error of Bech32m")
+		}
+		// the other amount with the valid checksum
+		dec, err := bolt11.Decode(bech32m.Encode("lnbc26m", append([]byte{}, data...), bech32m.Bech32))
+		if payeeField {
+			if err == nil {
+				t.Errorf("no error of the invalid signature")
+			}
+		} else if err != nil || bytes.Equal(dec.Payee.Compressed(), inv.Payee.Compressed()) {
+			t.Errorf("recovered the same payee %v", err)
+		}
+	}
+	// the illegal amounts
+	s, _ := inv.Sign(x)
+	_, data, _, _ := bech32m.DecodeWithLimit(s, 0)
+	for _, hrp := range []string{"lnbc2500000001p", "lnbc2500x", "lnbc0m", "lnbc99999999999", "lnbc25mm", "lnbc-1"} {
+		_, err := bolt11.Decode(bech32m.Encode(hrp, append([]byte{}, data...), bech32m.Bech32))
+		if err == nil {
+			t.Errorf("%s : no error", hrp)
+		}
+	}
+}
@@ -28,7 +28,7 @@ func (point *Point) Infinite() bool {
 func (point *Point) Clone() *Point {
 	clone := &Point{}
 	if point.Infinite() {
-		return nil
+		return clone
 	}
 	clone.X = new(big.Int).SetBytes(point.X.Bytes())
 	clone.Y = new(big.Int).SetBytes(point.Y.Bytes())
@@ -84,6 +84,20 @@ func Decode(bs []byte) (*Point, error) {
 	return point, nil
 }
 
+// OnCurve returns whether Point is on the curve, y^2 = x^3 + 7 mod p.
+// Decode does not check this; callers must check Points decoded from untrusted data.
+func (point *Point) OnCurve() bool {
+	if point.Infinite() {
+		return false
+	}
+	if point.X.Sign() < 0 || point.X.Cmp(p) >= 0 || point.Y.Sign() < 0 || point.Y.Cmp(p) >= 0 {
+		return false
+	}
+	y2 := new(big.Int).Mod(new(big.Int).Mul(point.Y, point.Y), p)
+	x3 := new(big.Int).Mod(new(big.Int).Add(new(big.Int).Exp(point.X, big.NewInt(3), p), big.NewInt(7)), p)
+	return y2.Cmp(x3) == 0
+}
+
 // DecodeString returns a Point from the hexstring.
 func DecodeString(hexstring string) (*Point, error) {
 	bs, err := hex.DecodeString(hexstring)
@@ -107,7 +121,7 @@ func Add(P, Q *Point) *Point {
 	if Q.Infinite() {
 		return P.Clone()
 	}
-	if P.X.Cmp(Q.X) == 0 && Q.Y.Cmp(Q.Y) != 0 {
+	if P.X.Cmp(Q.X) == 0 && P.Y.Cmp(Q.Y) != 0 {
 		return &Point{}
 	}
 	var s *big.Int
@@ -140,6 +154,14 @@ func Add(P, Q *Point) *Point {
 	return R
 }
 
+// Neg returns the negation of Point.
+func Neg(P *Point) *Point {
+	if P.Infinite() {
+		return &Point{}
+	}
+	return &Point{X: new(big.Int).Set(P.X), Y: new(big.Int).Mod(new(big.Int).Neg(P.Y), p)}
+}
+
 // Mul is the multiple of Point.
 func Mul(x *big.Int, P *Point) *Point {
 	R := &Point{}
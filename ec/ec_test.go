@@ -56,3 +56,24 @@ func TestVector(t *testing.T) {
 	}
 	t.Log(cnt)
 }
+
+func TestOnCurve(t *testing.T) {
+	if !ec.G.OnCurve() {
+		t.Errorf("G is not on curve")
+	}
+	if (&ec.Point{}).OnCurve() {
+		t.Errorf("infinity is on curve")
+	}
+	P := ec.G.Clone()
+	P.Y.Add(P.Y, big.NewInt(1))
+	if P.OnCurve() {
+		t.Errorf("(x, y + 1) is on curve")
+	}
+	// x + p is the same x mod p, but it is not canonical.
+	p, _ := new(big.Int).SetString("FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEFFFFFC2F", 16)
+	P = ec.G.Clone()
+	P.X.Add(P.X, p)
+	if P.OnCurve() {
+		t.Errorf("(x + p, y) is on curve")
+	}
+}
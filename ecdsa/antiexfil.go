@@ -0,0 +1,81 @@
+package ecdsa
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"math/big"
+
+	"github.com/tnakagawa/goref/ec"
+)
+
+// Anti-Exfil (sign-to-contract) protocol
+// https://github.com/BlockstreamResearch/secp256k1-zkp/blob/master/include/secp256k1_ecdsa_s2c.h
+//
+// The host contributes entropy to the nonce so that a malicious signer can not leak
+// the secret key through the nonce.
+//  1. The host draws 32 random bytes rho and sends HostCommit(rho) to the signer.
+//  2. The signer sends its original nonce point R0 = SignerCommit(m, x, commitment) to the host.
+//  3. The host sends rho to the signer, and the signer returns AntiExfilSign(m, x, rho).
+//  4. The host checks the signature with AntiExfilHostVerify(P, m, r, s, rho, R0).
+
+// taggedHash returns SHA256(SHA256(tag) || SHA256(tag) || x).
+func taggedHash(tag string, values ...[]byte) []byte {
+	t := sha256.Sum256([]byte(tag))
+	h := sha256.New()
+	h.Write(t[:])
+	h.Write(t[:])
+	for _, value := range values {
+		h.Write(value)
+	}
+	return h.Sum(nil)
+}
+
+// HostCommit returns the commitment to the host data rho.
+func HostCommit(rho []byte) []byte {
+	return taggedHash("s2c/ecdsa/data", rho)
+}
+
+// s2cTweak returns the tweak t = hash(R0 || rho) mod n.
+func s2cTweak(R0 *ec.Point, rho []byte) *big.Int {
+	t := new(big.Int).SetBytes(taggedHash("s2c/ecdsa/point", R0.Compressed(), rho))
+	return t.Mod(t, n)
+}
+
+// SignerCommit returns the original nonce point R0 that commits to the host commitment.
+func SignerCommit(m []byte, x *big.Int, commitment []byte) *ec.Point {
+	k0 := nonceRFC6979(m, x, commitment)
+	return ec.Mul(k0, ec.G)
+}
+
+// AntiExfilSign returns the signature whose nonce commits to the host data rho.
+func AntiExfilSign(m []byte, x *big.Int, rho []byte) (*big.Int, *big.Int, error) {
+	if len(rho) != 32 {
+		return nil, nil, fmt.Errorf("illegal host data size : %d", len(rho))
+	}
+	// k0 is the nonce of SignerCommit.
+	k0 := nonceRFC6979(m, x, HostCommit(rho))
+	R0 := ec.Mul(k0, ec.G)
+	// k = k0 + hash(R0 || rho) mod n
+	k := new(big.Int).Mod(new(big.Int).Add(k0, s2cTweak(R0, rho)), n)
+	if k.Sign() == 0 {
+		return nil, nil, fmt.Errorf("k = 0")
+	}
+	r, s := sign(m, x, k)
+	return r, s, nil
+}
+
+// AntiExfilHostVerify verifies the signature in r, s of message using the public key, P,
+// and that the nonce of the signature is R0 + hash(R0 || rho)G.
+func AntiExfilHostVerify(P *ec.Point, m []byte, r, s *big.Int, rho []byte, R0 *ec.Point) bool {
+	if len(rho) != 32 || R0 == nil || R0.Infinite() {
+		return false
+	}
+	if !Verify(P, m, r, s) {
+		return false
+	}
+	R := ec.Add(R0, ec.Mul(s2cTweak(R0, rho), ec.G))
+	if R.Infinite() || r.Cmp(new(big.Int).Mod(R.X, n)) != 0 {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,63 @@
+package ecdsa_test
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/tnakagawa/goref/ec"
+	"github.com/tnakagawa/goref/ecdsa"
+)
+
+func TestAntiExfil(t *testing.T) {
+	loop := 10
+	for i := 0; i < loop; i++ {
+		m := make([]byte, 32)
+		rand.Read(m)
+		key, err := btcec.NewPrivateKey(btcec.S256())
+		if err != nil {
+			t.Errorf("%v", err)
+			return
+		}
+		P := ec.Mul(key.D, ec.G)
+		// host
+		rho := make([]byte, 32)
+		rand.Read(rho)
+		commitment := ecdsa.HostCommit(rho)
+		// signer
+		R0 := ecdsa.SignerCommit(m, key.D, commitment)
+		r, s, err := ecdsa.AntiExfilSign(m, key.D, rho)
+		if err != nil {
+			t.Errorf("%v", err)
+			return
+		}
+		// host verify
+		if !ecdsa.AntiExfilHostVerify(P, m, r, s, rho, R0) {
+			t.Errorf("anti-exfil verify error")
+			return
+		}
+		// btcec verify
+		sig, err := btcec.ParseDERSignature(ecdsa.DER(r, s), btcec.S256())
+		if err != nil {
+			t.Errorf("%v", err)
+			return
+		}
+		if !sig.Verify(ecdsa.H(m), key.PubKey()) {
+			t.Errorf("verify error")
+			return
+		}
+		// the nonce does not commit to other host data
+		other := make([]byte, 32)
+		rand.Read(other)
+		if ecdsa.AntiExfilHostVerify(P, m, r, s, other, R0) {
+			t.Errorf("anti-exfil verify must fail with other host data")
+			return
+		}
+		// the signer does not use the committed nonce
+		r2, s2 := ecdsa.Sign(m, key.D)
+		if ecdsa.AntiExfilHostVerify(P, m, r2, s2, rho, R0) {
+			t.Errorf("anti-exfil verify must fail with a plain signature")
+			return
+		}
+	}
+}
@@ -64,13 +64,20 @@ func HMAC(key []byte, values ...[]byte) []byte {
 
 // 3.2.  Generation of k
 // https://tools.ietf.org/html/rfc6979#section-3.2
-func nonceRFC6979(m []byte, x *big.Int) *big.Int {
-	h1 := H(m)
+// The optional extra data is appended to the HMAC inputs of steps d and f.
+// 3.6.  Variants
+// https://tools.ietf.org/html/rfc6979#section-3.6
+func nonceRFC6979(m []byte, x *big.Int, extra ...[]byte) *big.Int {
+	return nonceHash(H(m), x, extra...)
+}
+
+// nonceHash returns the nonce of RFC6979 for the hash h1 = H(m).
+func nonceHash(h1 []byte, x *big.Int, extra ...[]byte) *big.Int {
 	V := bytes.Repeat([]byte{0x01}, len(h1))
 	K := make([]byte, len(h1))
-	K = HMAC(K, V, []byte{0x00}, int2octets(x), h1)
+	K = HMAC(K, append([][]byte{V, {0x00}, int2octets(x), h1}, extra...)...)
 	V = HMAC(K, V)
-	K = HMAC(K, V, []byte{0x01}, int2octets(x), h1)
+	K = HMAC(K, append([][]byte{V, {0x01}, int2octets(x), h1}, extra...)...)
 	V = HMAC(K, V)
 	for {
 		T := []byte{}
@@ -93,10 +100,26 @@ func nonceRFC6979(m []byte, x *big.Int) *big.Int {
 // 2.4.  Signature Generation
 // https://tools.ietf.org/html/rfc6979#section-2.4
 func Sign(m []byte, x *big.Int) (*big.Int, *big.Int) {
-	h := new(big.Int).Mod(bits2int(H(m)), n)
 	k := nonceRFC6979(m, x)
+	return sign(m, x, k)
+}
+
+// sign returns the signature of message using the nonce k.
+func sign(m []byte, x, k *big.Int) (*big.Int, *big.Int) {
+	r, s, _ := signHash(H(m), x, k)
+	return r, s
+}
+
+// signHash returns the signature and the recovery id of the hash using the nonce k.
+func signHash(hash []byte, x, k *big.Int) (*big.Int, *big.Int, byte) {
+	h := new(big.Int).Mod(bits2int(hash), n)
 	R := ec.Mul(k, ec.G)
-	r := R.X
+	r := new(big.Int).Mod(R.X, n)
+	// the recovery id, the parity of R.y and whether R.x >= n
+	v := byte(R.Y.Bit(0))
+	if R.X.Cmp(n) >= 0 {
+		v |= 2
+	}
 	// s = (h + x*r) * k^(q-2)
 	s := new(big.Int).Mod(
 		new(big.Int).Mul(
@@ -105,27 +128,35 @@ func Sign(m []byte, x *big.Int) (*big.Int, *big.Int) {
 		n)
 	half := new(big.Int).Rsh(n, 1)
 	if s.Cmp(half) > 0 {
+		// -s is the signature by -k, whose R.y has the other parity
 		s.Sub(n, s)
+		v ^= 1
 	}
-	return r, s
+	return r, s, v
 }
 
 // Verify verifies the signature in r, s of message using the public key, P.
 // https://apps.nsa.gov/iaarchive/library/index.cfm
 // "Suite B Implementer’s Guide to FIPS 186-3 (ECDSA)"
 func Verify(P *ec.Point, m []byte, r, s *big.Int) bool {
-	if r.Cmp(big.NewInt(1)) < 0 && r.Cmp(n) >= 0 {
+	return VerifyHash(P, H(m), r, s)
+}
+
+// VerifyHash verifies the signature in r, s of the hash using the public key, P.
+// The hash is not hashed again, so that the signatures over the other hashes like a single SHA256 are verified.
+func VerifyHash(P *ec.Point, hash []byte, r, s *big.Int) bool {
+	if r.Cmp(big.NewInt(1)) < 0 || r.Cmp(n) >= 0 {
 		return false
 	}
-	if s.Cmp(big.NewInt(1)) < 0 && s.Cmp(n) >= 0 {
+	if s.Cmp(big.NewInt(1)) < 0 || s.Cmp(n) >= 0 {
 		return false
 	}
-	e := bits2int(H(m))
+	e := bits2int(hash)
 	w := new(big.Int).Exp(s, new(big.Int).Sub(n, big.NewInt(2)), n)
 	u1 := new(big.Int).Mod(new(big.Int).Mul(e, w), n)
 	u2 := new(big.Int).Mod(new(big.Int).Mul(r, w), n)
 	V := ec.Add(ec.Mul(u1, ec.G), ec.Mul(u2, P))
-	if V.Infinite() || r.Cmp(V.X) != 0 {
+	if V.Infinite() || r.Cmp(new(big.Int).Mod(V.X, n)) != 0 {
 		return false
 	}
 	return true
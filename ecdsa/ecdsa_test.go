@@ -2,6 +2,7 @@ package ecdsa_test
 
 import (
 	"crypto/rand"
+	"math/big"
 	"testing"
 
 	"github.com/btcsuite/btcd/btcec"
@@ -48,3 +49,29 @@ func TestECDSA(t *testing.T) {
 		}
 	}
 }
+
+func TestVerifyRange(t *testing.T) {
+	n, _ := new(big.Int).SetString("FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEBAAEDCE6AF48A03BBFD25E8CD0364141", 16)
+	m := []byte("range")
+	x := big.NewInt(12345)
+	P := ec.Mul(x, ec.G)
+	r, s := ecdsa.Sign(m, x)
+	if r.Sign() <= 0 || r.Cmp(n) >= 0 || !ecdsa.Verify(P, m, r, s) {
+		t.Errorf("verify error %x %x", r, s)
+		return
+	}
+	for _, c := range []struct {
+		name string
+		r, s *big.Int
+	}{
+		{"r = 0", big.NewInt(0), s},
+		{"s = 0", r, big.NewInt(0)},
+		{"r + n", new(big.Int).Add(r, n), s},
+		{"s + n", r, new(big.Int).Add(s, n)},
+		{"s = n", r, n},
+	} {
+		if ecdsa.Verify(P, m, c.r, c.s) {
+			t.Errorf("%s : verified", c.name)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package ecdsa
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/tnakagawa/goref/ec"
+)
+
+// Public Key Recovery Operation
+// SEC 1: Elliptic Curve Cryptography 4.1.6
+// https://www.secg.org/sec1-v2.pdf
+//
+// The recovery id v has 2 bits, the bit 0 is the parity of R.y and the bit 1 is whether R.x >= n,
+// so that the public key is recovered from the signature (r, s, v) of the hash e.
+//  R = (r + (v >> 1) * n, y), where y has the parity v & 1
+//  Q = r^-1 * (s * R - e * G)
+
+// SignRecoverable returns the signature and the recovery id of the hash, which is not hashed again.
+// The nonce is RFC6979 with the hash, so that the signature is the same as the compact signature of libsecp256k1.
+func SignRecoverable(hash []byte, x *big.Int) (*big.Int, *big.Int, byte) {
+	k := nonceHash(hash, x)
+	return signHash(hash, x, k)
+}
+
+// RecoverPubKey returns the public key from the signature and the recovery id of the hash.
+func RecoverPubKey(hash []byte, r, s *big.Int, v byte) (*ec.Point, error) {
+	if r.Sign() <= 0 || r.Cmp(n) >= 0 || s.Sign() <= 0 || s.Cmp(n) >= 0 {
+		return nil, fmt.Errorf("r or s is out of range")
+	}
+	if v > 3 {
+		return nil, fmt.Errorf("illegal recovery id : %d", v)
+	}
+	// R.x = r + (v >> 1) * n
+	x := new(big.Int).Set(r)
+	if v&2 != 0 {
+		x.Add(x, n)
+	}
+	if x.BitLen() > 256 {
+		return nil, fmt.Errorf("R.x is out of range")
+	}
+	bs := make([]byte, 33)
+	bs[0] = 0x02 | v&1
+	copy(bs[33-len(x.Bytes()):], x.Bytes())
+	R, err := ec.Decode(bs)
+	if err != nil {
+		return nil, err
+	}
+	if !R.OnCurve() {
+		return nil, fmt.Errorf("R is not on the curve")
+	}
+	// u1 = -e * r^-1, u2 = s * r^-1
+	e := new(big.Int).Mod(bits2int(hash), n)
+	ri := new(big.Int).ModInverse(r, n)
+	u1 := new(big.Int).Mod(new(big.Int).Mul(new(big.Int).Neg(e), ri), n)
+	u2 := new(big.Int).Mod(new(big.Int).Mul(s, ri), n)
+	Q := ec.Add(ec.Mul(u1, ec.G), ec.Mul(u2, R))
+	if Q.Infinite() {
+		return nil, fmt.Errorf("the public key is at infinity")
+	}
+	return Q, nil
+}
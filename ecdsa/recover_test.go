@@ -0,0 +1,72 @@
+package ecdsa_test
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"math/big"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/tnakagawa/goref/ec"
+	"github.com/tnakagawa/goref/ecdsa"
+)
+
+func TestRecover(t *testing.T) {
+	loop := 20
+	for i := 0; i < loop; i++ {
+		m := make([]byte, 32)
+		rand.Read(m)
+		hash := sha256.Sum256(m)
+		key, err := btcec.NewPrivateKey(btcec.S256())
+		if err != nil {
+			t.Errorf("%v", err)
+			return
+		}
+		// btcec compact signature, 27 + 4 + v || r || s
+		compact, err := btcec.SignCompact(btcec.S256(), key, hash[:], true)
+		if err != nil {
+			t.Errorf("%v", err)
+			return
+		}
+		r, s, v := ecdsa.SignRecoverable(hash[:], key.D)
+		if compact[0] != 27+4+v || r.Cmp(new(big.Int).SetBytes(compact[1:33])) != 0 || s.Cmp(new(big.Int).SetBytes(compact[33:])) != 0 {
+			t.Errorf("not match %x %x %x %d", compact, r, s, v)
+			return
+		}
+		P := ec.Mul(key.D, ec.G)
+		if !ecdsa.VerifyHash(P, hash[:], r, s) {
+			t.Errorf("verify error")
+			return
+		}
+		Q, err := ecdsa.RecoverPubKey(hash[:], r, s, v)
+		if err != nil {
+			t.Errorf("%v", err)
+			return
+		}
+		if !bytes.Equal(Q.Compressed(), P.Compressed()) {
+			t.Errorf("not match %x %x", Q.Compressed(), P.Compressed())
+			return
+		}
+		// the other parity recovers the other key
+		Q, err = ecdsa.RecoverPubKey(hash[:], r, s, v^1)
+		if err == nil && bytes.Equal(Q.Compressed(), P.Compressed()) {
+			t.Errorf("recovered by the wrong id")
+			return
+		}
+	}
+	hash := sha256.Sum256([]byte("recover"))
+	for _, c := range []struct {
+		r, s *big.Int
+		v    byte
+	}{
+		{big.NewInt(0), big.NewInt(1), 0},
+		{big.NewInt(1), big.NewInt(0), 0},
+		{big.NewInt(1), big.NewInt(1), 4},
+	} {
+		_, err := ecdsa.RecoverPubKey(hash[:], c.r, c.s, c.v)
+		if err == nil {
+			t.Errorf("no error %v %v %d", c.r, c.s, c.v)
+		}
+	}
+}
@@ -0,0 +1,466 @@
+package frost
+
+// FROST: Flexible Round-Optimized Schnorr Threshold Signatures
+// https://eprint.iacr.org/2020/852.pdf
+// https://www.rfc-editor.org/rfc/rfc9591.html
+//
+// The signatures are BIP340 signatures of the x-only group public key, which are verifiable with schnorr.Verify.
+//
+// Distributed key generation (Pedersen DKG with proofs of knowledge):
+//  1. Each participant calls Round1 with the context string Φ of the session and broadcasts the Round1Package.
+//  2. Each participant calls Round2 with all packages and sends the secret share to each participant privately.
+//  3. Each participant calls Finalize with the secret shares sent to it.
+//
+// Signing:
+//  1. Each signer calls Commit and sends the Commitment to the signature aggregator.
+//  2. The aggregator sends the message and the commitments to the signers, and each signer returns Sign.
+//  3. The aggregator calls Group.Aggregate, which verifies every signature share and identifies the misbehaving signers.
+
+import (
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/tnakagawa/goref/ec"
+	"github.com/tnakagawa/goref/schnorr"
+)
+
+// The constant n refers to the curve order, 0xFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEBAAEDCE6AF48A03BBFD25E8CD0364141.
+var n, _ = new(big.Int).SetString("FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEBAAEDCE6AF48A03BBFD25E8CD0364141", 16)
+
+// AbortError identifies the participant who misbehaved.
+type AbortError struct {
+	Participant int
+	Reason      string
+}
+
+func (e *AbortError) Error() string {
+	return fmt.Sprintf("participant %d : %s", e.Participant, e.Reason)
+}
+
+// bytes32 returns the 32-byte encoding of x, most significant byte first.
+func bytes32(x *big.Int) []byte {
+	bs := make([]byte, 32)
+	return x.FillBytes(bs)
+}
+
+// hasEvenY returns y(P) mod 2 = 0.
+func hasEvenY(P *ec.Point) bool {
+	return P.Y.Bit(0) == 0
+}
+
+// equal returns whether P = Q.
+func equal(P, Q *ec.Point) bool {
+	if P.Infinite() || Q.Infinite() {
+		return P.Infinite() && Q.Infinite()
+	}
+	return P.X.Cmp(Q.X) == 0 && P.Y.Cmp(Q.Y) == 0
+}
+
+// hash returns int(hash_tag(x1 || x2 || ...)) mod n.
+func hash(tag string, xs ...[]byte) *big.Int {
+	h := new(big.Int).SetBytes(schnorr.TaggedHash(tag, bytes.Join(xs, nil)))
+	return h.Mod(h, n)
+}
+
+// random returns a random integer in the range 1..n-1.
+func random() (*big.Int, error) {
+	k, err := rand.Int(rand.Reader, new(big.Int).Sub(n, big.NewInt(1)))
+	if err != nil {
+		return nil, err
+	}
+	return k.Add(k, big.NewInt(1)), nil
+}
+
+// polynomial returns f(x) = a0 + a1⋅x + ... + at-1⋅x^(t-1) mod n.
+func polynomial(coefficients []*big.Int, x int) *big.Int {
+	y := big.NewInt(0)
+	for i := len(coefficients) - 1; i >= 0; i-- {
+		y.Mul(y, big.NewInt(int64(x)))
+		y.Add(y, coefficients[i])
+		y.Mod(y, n)
+	}
+	return y
+}
+
+// polynomialPoint returns C0 + x⋅C1 + ... + x^(t-1)⋅Ct-1, which is f(x)⋅G for the commitments Cj = aj⋅G.
+func polynomialPoint(commitments []*ec.Point, x int) *ec.Point {
+	P := &ec.Point{}
+	xj := big.NewInt(1)
+	for _, C := range commitments {
+		P = ec.Add(P, ec.Mul(xj, C))
+		xj = new(big.Int).Mod(new(big.Int).Mul(xj, big.NewInt(int64(x))), n)
+	}
+	return P
+}
+
+// lagrange returns the Lagrange coefficient λi = Π j / (j - i) mod n for j in the participants except i.
+func lagrange(i int, participants []int) *big.Int {
+	num := big.NewInt(1)
+	den := big.NewInt(1)
+	for _, j := range participants {
+		if j == i {
+			continue
+		}
+		num.Mod(num.Mul(num, big.NewInt(int64(j))), n)
+		den.Mod(den.Mul(den, big.NewInt(int64(j-i))), n)
+	}
+	return num.Mod(num.Mul(num, new(big.Int).ModInverse(den, n)), n)
+}
+
+// pokChallenge returns ci = H(i || Φ || C_i0 || Ri) for the proof of knowledge of ai0.
+// Φ is the only field of the variable length, so that the concatenation is not ambiguous.
+func pokChallenge(i int, context []byte, C0, R *ec.Point) *big.Int {
+	return hash("FROST/pok", bytes32(big.NewInt(int64(i))), context, C0.Compressed(), R.Compressed())
+}
+
+// Group is the result of the distributed key generation shared by all participants.
+type Group struct {
+	T                  int               // threshold
+	N                  int               // number of participants
+	PubKey             *ec.Point         // group public key Y
+	VerificationShares map[int]*ec.Point // verification share Yi = si⋅G of participant i
+}
+
+// XonlyPK returns the x-only group public key for schnorr.Verify.
+func (g *Group) XonlyPK() []byte {
+	return bytes32(g.PubKey.X)
+}
+
+// Round1Package is broadcast in the round 1 of the distributed key generation.
+type Round1Package struct {
+	Index       int         // participant i
+	Commitments []*ec.Point // Cij = aij⋅G for j = 0..t-1
+	R           *ec.Point   // proof of knowledge of ai0, Ri = k⋅G
+	Mu          *big.Int    // proof of knowledge of ai0, μi = k + ai0⋅ci
+}
+
+// Participant is a participant of the distributed key generation and the signing.
+type Participant struct {
+	Index        int
+	t            int
+	n            int
+	context      []byte // context string Φ of the distributed key generation
+	coefficients []*big.Int
+	commitments  map[int][]*ec.Point
+	share        *big.Int // secret share si
+	Group        *Group
+	nonce        []*big.Int // (di, ei), which is used only once
+	commitment   *Commitment
+}
+
+// NewParticipant returns the participant i of the t-of-n group.
+func NewParticipant(i, t, n int) (*Participant, error) {
+	if t < 1 || n < t {
+		return nil, fmt.Errorf("illegal threshold %d of %d", t, n)
+	}
+	if i < 1 || n < i {
+		return nil, fmt.Errorf("illegal index %d", i)
+	}
+	return &Participant{Index: i, t: t, n: n}, nil
+}
+
+// Round1 samples the polynomial and returns the commitments with the proof of knowledge.
+// The context string Φ must be unique to the session and shared by all participants,
+// so that the proofs of knowledge are not replayed in the other sessions.
+func (p *Participant) Round1(context []byte) (*Round1Package, error) {
+	p.context = append([]byte{}, context...)
+	// Sample t random values ai0, ..., ai(t-1) and use these values as coefficients to define a polynomial fi(x).
+	p.coefficients = []*big.Int{}
+	for j := 0; j < p.t; j++ {
+		a, err := random()
+		if err != nil {
+			return nil, err
+		}
+		p.coefficients = append(p.coefficients, a)
+	}
+	// Compute a proof of knowledge to the corresponding secret ai0 by calculating σi = (Ri, μi),
+	// such that k ← Zq, Ri = g^k, ci = H(i, Φ, g^ai0, Ri), μi = k + ai0 · ci.
+	k, err := random()
+	if err != nil {
+		return nil, err
+	}
+	pkg := &Round1Package{Index: p.Index}
+	// Compute a public commitment Ci = <φi0, ..., φi(t-1)>, where φij = g^aij.
+	for _, a := range p.coefficients {
+		pkg.Commitments = append(pkg.Commitments, ec.Mul(a, ec.G))
+	}
+	pkg.R = ec.Mul(k, ec.G)
+	c := pokChallenge(p.Index, p.context, pkg.Commitments[0], pkg.R)
+	pkg.Mu = new(big.Int).Mod(new(big.Int).Add(k, new(big.Int).Mul(p.coefficients[0], c)), n)
+	return pkg, nil
+}
+
+// Round2 verifies the packages of round 1 and returns the secret share fi(l) for each participant l.
+func (p *Participant) Round2(pkgs []*Round1Package) (map[int]*big.Int, error) {
+	if p.coefficients == nil {
+		return nil, fmt.Errorf("round 1 is not done")
+	}
+	if len(pkgs) != p.n {
+		return nil, fmt.Errorf("illegal number of packages : %d", len(pkgs))
+	}
+	p.commitments = map[int][]*ec.Point{}
+	for _, pkg := range pkgs {
+		if pkg.Index < 1 || p.n < pkg.Index || p.commitments[pkg.Index] != nil {
+			return nil, &AbortError{Participant: pkg.Index, Reason: "illegal index"}
+		}
+		if len(pkg.Commitments) != p.t || pkg.R == nil || pkg.Mu == nil {
+			return nil, &AbortError{Participant: pkg.Index, Reason: "illegal package"}
+		}
+		// Verify σl = (Rl, μl), aborting on failure, by checking Rl = g^μl · φl0^-cl, where cl = H(l, Φ, φl0, Rl).
+		c := pokChallenge(pkg.Index, p.context, pkg.Commitments[0], pkg.R)
+		R := ec.Add(ec.Mul(pkg.Mu, ec.G), ec.Mul(new(big.Int).Sub(n, c), pkg.Commitments[0]))
+		if !equal(R, pkg.R) {
+			return nil, &AbortError{Participant: pkg.Index, Reason: "invalid proof of knowledge"}
+		}
+		p.commitments[pkg.Index] = pkg.Commitments
+	}
+	// Each Pi securely sends to each other participant Pl a secret share (l, fi(l)), deleting f_i and each share afterward except for (i, fi(i)), which they keep for themselves.
+	shares := map[int]*big.Int{}
+	for l := 1; l <= p.n; l++ {
+		shares[l] = polynomial(p.coefficients, l)
+	}
+	return shares, nil
+}
+
+// Finalize verifies the secret shares fl(i) sent by each participant l and computes the group.
+func (p *Participant) Finalize(shares map[int]*big.Int) error {
+	if p.commitments == nil {
+		return fmt.Errorf("round 2 is not done")
+	}
+	if len(shares) != p.n {
+		return fmt.Errorf("illegal number of shares : %d", len(shares))
+	}
+	s := big.NewInt(0)
+	for l := 1; l <= p.n; l++ {
+		share, ok := shares[l]
+		if !ok {
+			return &AbortError{Participant: l, Reason: "no secret share"}
+		}
+		// Each Pi verifies their shares by calculating: g^fl(i) = Π φlk^(i^k mod q), aborting if the check fails.
+		if share.Sign() < 0 || share.Cmp(n) >= 0 || !equal(ec.Mul(share, ec.G), polynomialPoint(p.commitments[l], p.Index)) {
+			return &AbortError{Participant: l, Reason: "invalid secret share"}
+		}
+		// Each Pi calculates their long-lived private signing share by computing si = Σ fl(i).
+		s.Add(s, share)
+	}
+	s.Mod(s, n)
+	// Each Pi calculates their public verification share Yi = g^si, and the public verification key Y = Π φj0.
+	group := &Group{T: p.t, N: p.n, PubKey: &ec.Point{}, VerificationShares: map[int]*ec.Point{}}
+	for l := 1; l <= p.n; l++ {
+		group.PubKey = ec.Add(group.PubKey, p.commitments[l][0])
+	}
+	if group.PubKey.Infinite() {
+		return fmt.Errorf("infinite(Y)")
+	}
+	for i := 1; i <= p.n; i++ {
+		Y := &ec.Point{}
+		for l := 1; l <= p.n; l++ {
+			Y = ec.Add(Y, polynomialPoint(p.commitments[l], i))
+		}
+		group.VerificationShares[i] = Y
+	}
+	p.share = s
+	p.Group = group
+	p.coefficients = nil
+	p.commitments = nil
+	return nil
+}
+
+// Commitment is the commitment (i, Di, Ei) of the signer i.
+type Commitment struct {
+	Index int
+	D     *ec.Point // hiding nonce commitment
+	E     *ec.Point // binding nonce commitment
+}
+
+// nonceGenerate returns the nonce H(random_bytes || si).
+func (p *Participant) nonceGenerate() (*big.Int, error) {
+	for {
+		rnd := make([]byte, 32)
+		_, err := rand.Read(rnd)
+		if err != nil {
+			return nil, err
+		}
+		k := hash("FROST/nonce", rnd, bytes32(p.share))
+		if k.Sign() != 0 {
+			return k, nil
+		}
+	}
+}
+
+// Commit returns the commitment of the new pair of nonces (di, ei).
+// The nonces are used only once by the next Sign.
+func (p *Participant) Commit() (*Commitment, error) {
+	if p.Group == nil {
+		return nil, fmt.Errorf("key generation is not done")
+	}
+	d, err := p.nonceGenerate()
+	if err != nil {
+		return nil, err
+	}
+	e, err := p.nonceGenerate()
+	if err != nil {
+		return nil, err
+	}
+	p.nonce = []*big.Int{d, e}
+	p.commitment = &Commitment{Index: p.Index, D: ec.Mul(d, ec.G), E: ec.Mul(e, ec.G)}
+	return p.commitment, nil
+}
+
+// signingContext is the values computed from the message and the commitments.
+type signingContext struct {
+	participants []int
+	rho          map[int]*big.Int  // binding factor ρi
+	Ri           map[int]*ec.Point // commitment share Ri = Di + ρi⋅Ei
+	R            *ec.Point         // group commitment R
+	c            *big.Int          // challenge
+}
+
+// context computes the binding factors, the group commitment and the challenge.
+func (g *Group) context(m []byte, commitments []*Commitment) (*signingContext, error) {
+	if len(commitments) < g.T || len(commitments) > g.N {
+		return nil, fmt.Errorf("illegal number of commitments : %d", len(commitments))
+	}
+	for _, com := range commitments {
+		if com == nil {
+			return nil, &AbortError{Reason: "missing commitment"}
+		}
+	}
+	sorted := make([]*Commitment, len(commitments))
+	copy(sorted, commitments)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Index < sorted[j].Index
+	})
+	// encode_group_commitment_list(commitment_list)
+	encoded := []byte{}
+	ctx := &signingContext{rho: map[int]*big.Int{}, Ri: map[int]*ec.Point{}, R: &ec.Point{}}
+	for i, com := range sorted {
+		if com.Index < 1 || g.N < com.Index || (i > 0 && sorted[i-1].Index == com.Index) {
+			return nil, &AbortError{Participant: com.Index, Reason: "illegal index"}
+		}
+		if com.D == nil || com.D.Infinite() || com.E == nil || com.E.Infinite() {
+			return nil, &AbortError{Participant: com.Index, Reason: "illegal commitment"}
+		}
+		ctx.participants = append(ctx.participants, com.Index)
+		encoded = append(encoded, bytes32(big.NewInt(int64(com.Index)))...)
+		encoded = append(encoded, com.D.Compressed()...)
+		encoded = append(encoded, com.E.Compressed()...)
+	}
+	// rho_input_prefix = SerializeElement(group_public_key) || H4(msg) || H5(encoded_commitment_hash)
+	prefix := [][]byte{bytes32(g.PubKey.X), schnorr.TaggedHash("FROST/msg", m), schnorr.TaggedHash("FROST/com", encoded)}
+	for _, com := range sorted {
+		// binding_factor = H1(rho_input_prefix || SerializeScalar(identifier))
+		rho := hash("FROST/rho", append(prefix, bytes32(big.NewInt(int64(com.Index))))...)
+		ctx.rho[com.Index] = rho
+		// R = Σ Di + ρi⋅Ei
+		Ri := ec.Add(com.D, ec.Mul(rho, com.E))
+		ctx.Ri[com.Index] = Ri
+		ctx.R = ec.Add(ctx.R, Ri)
+	}
+	if ctx.R.Infinite() {
+		return nil, fmt.Errorf("infinite(R)")
+	}
+	// c = int(hash_BIP0340/challenge(bytes(R) || bytes(Y) || m)) mod n
+	ctx.c = hash("BIP0340/challenge", bytes32(ctx.R.X), bytes32(g.PubKey.X), m)
+	return ctx, nil
+}
+
+// Sign returns the signature share zi = di + ei⋅ρi + λi⋅si⋅c.
+// The nonces of Commit are deleted, so that Commit must be called before the next Sign.
+func (p *Participant) Sign(m []byte, commitments []*Commitment) (*big.Int, error) {
+	if p.nonce == nil {
+		return nil, fmt.Errorf("no nonce")
+	}
+	d, e := p.nonce[0], p.nonce[1]
+	own := p.commitment
+	p.nonce = nil
+	p.commitment = nil
+	found := false
+	for _, com := range commitments {
+		if com == nil {
+			return nil, &AbortError{Reason: "missing commitment"}
+		}
+		if com.Index == p.Index {
+			found = equal(com.D, own.D) && equal(com.E, own.E)
+			break
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("the commitment of the signer is not included")
+	}
+	ctx, err := p.Group.context(m, commitments)
+	if err != nil {
+		return nil, err
+	}
+	// The nonces are negated if the group commitment R has odd Y for BIP340.
+	if !hasEvenY(ctx.R) {
+		d = new(big.Int).Sub(n, d)
+		e = new(big.Int).Sub(n, e)
+	}
+	// The secret share is negated if the group public key Y has odd Y for BIP340.
+	s := p.share
+	if !hasEvenY(p.Group.PubKey) {
+		s = new(big.Int).Sub(n, s)
+	}
+	lambda := lagrange(p.Index, ctx.participants)
+	z := new(big.Int).Add(d, new(big.Int).Mul(e, ctx.rho[p.Index]))
+	z.Add(z, new(big.Int).Mul(new(big.Int).Mul(lambda, s), ctx.c))
+	return z.Mod(z, n), nil
+}
+
+// VerifyShare verifies the signature share zi of the signer i by checking zi⋅G = Ri + c⋅λi⋅Yi.
+func (g *Group) VerifyShare(i int, m []byte, commitments []*Commitment, z *big.Int) error {
+	ctx, err := g.context(m, commitments)
+	if err != nil {
+		return err
+	}
+	return g.verifyShare(ctx, i, z)
+}
+
+func (g *Group) verifyShare(ctx *signingContext, i int, z *big.Int) error {
+	Ri, ok := ctx.Ri[i]
+	if !ok {
+		return &AbortError{Participant: i, Reason: "no commitment"}
+	}
+	Yi, ok := g.VerificationShares[i]
+	if !ok {
+		return &AbortError{Participant: i, Reason: "no verification share"}
+	}
+	if z == nil || z.Sign() < 0 || z.Cmp(n) >= 0 {
+		return &AbortError{Participant: i, Reason: "signature share is out of range"}
+	}
+	if !hasEvenY(ctx.R) {
+		Ri = ec.Neg(Ri)
+	}
+	if !hasEvenY(g.PubKey) {
+		Yi = ec.Neg(Yi)
+	}
+	lambda := lagrange(i, ctx.participants)
+	right := ec.Add(Ri, ec.Mul(new(big.Int).Mul(ctx.c, lambda), Yi))
+	if !equal(ec.Mul(z, ec.G), right) {
+		return &AbortError{Participant: i, Reason: "invalid signature share"}
+	}
+	return nil
+}
+
+// Aggregate verifies the signature shares and returns the BIP340 signature bytes(R) || bytes(Σ zi).
+// It fails with the AbortError of the first signer whose share is invalid.
+func (g *Group) Aggregate(m []byte, commitments []*Commitment, shares map[int]*big.Int) ([]byte, error) {
+	ctx, err := g.context(m, commitments)
+	if err != nil {
+		return nil, err
+	}
+	z := big.NewInt(0)
+	for _, i := range ctx.participants {
+		err = g.verifyShare(ctx, i, shares[i])
+		if err != nil {
+			return nil, err
+		}
+		z.Add(z, shares[i])
+	}
+	z.Mod(z, n)
+	return append(bytes32(ctx.R.X), bytes32(z)...), nil
+}
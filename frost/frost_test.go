@@ -0,0 +1,281 @@
+package frost_test
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/tnakagawa/goref/frost"
+	"github.com/tnakagawa/goref/schnorr"
+)
+
+// network is an in-memory simulator that delivers the messages between the participants.
+type network struct {
+	t            int
+	n            int
+	participants map[int]*frost.Participant
+	// context is the context string Φ of the distributed key generation.
+	context []byte
+	// broadcast tampers the round 1 packages if it is not nil.
+	broadcast func(pkgs []*frost.Round1Package)
+	// send tampers the secret share from the participant l to the participant i if it is not nil.
+	send func(l, i int, share *big.Int) *big.Int
+}
+
+func newNetwork(t, n int) (*network, error) {
+	nw := &network{t: t, n: n, participants: map[int]*frost.Participant{}, context: []byte("frost test session")}
+	for i := 1; i <= n; i++ {
+		p, err := frost.NewParticipant(i, t, n)
+		if err != nil {
+			return nil, err
+		}
+		nw.participants[i] = p
+	}
+	return nw, nil
+}
+
+// keyGen runs the distributed key generation and returns the errors of each participant.
+func (nw *network) keyGen() (map[int]error, error) {
+	pkgs := []*frost.Round1Package{}
+	for i := 1; i <= nw.n; i++ {
+		pkg, err := nw.participants[i].Round1(nw.context)
+		if err != nil {
+			return nil, err
+		}
+		pkgs = append(pkgs, pkg)
+	}
+	if nw.broadcast != nil {
+		nw.broadcast(pkgs)
+	}
+	errs := map[int]error{}
+	// inbox[i][l] is the secret share from the participant l to the participant i.
+	inbox := map[int]map[int]*big.Int{}
+	for i := 1; i <= nw.n; i++ {
+		inbox[i] = map[int]*big.Int{}
+	}
+	for l := 1; l <= nw.n; l++ {
+		shares, err := nw.participants[l].Round2(pkgs)
+		if err != nil {
+			errs[l] = err
+			continue
+		}
+		for i, share := range shares {
+			if nw.send != nil {
+				share = nw.send(l, i, share)
+			}
+			inbox[i][l] = share
+		}
+	}
+	for i := 1; i <= nw.n; i++ {
+		if errs[i] != nil {
+			continue
+		}
+		err := nw.participants[i].Finalize(inbox[i])
+		if err != nil {
+			errs[i] = err
+		}
+	}
+	return errs, nil
+}
+
+// sign runs the signing of the signers and returns the signature.
+// The share of each signer is tampered by tamper if it is not nil.
+func (nw *network) sign(signers []int, m []byte, tamper func(i int, z *big.Int) *big.Int) ([]byte, error) {
+	commitments := []*frost.Commitment{}
+	for _, i := range signers {
+		com, err := nw.participants[i].Commit()
+		if err != nil {
+			return nil, err
+		}
+		commitments = append(commitments, com)
+	}
+	shares := map[int]*big.Int{}
+	for _, i := range signers {
+		z, err := nw.participants[i].Sign(m, commitments)
+		if err != nil {
+			return nil, err
+		}
+		if tamper != nil {
+			z = tamper(i, z)
+		}
+		shares[i] = z
+	}
+	// Any participant can be the signature aggregator.
+	return nw.participants[signers[0]].Group.Aggregate(m, commitments, shares)
+}
+
+func TestFROST(t *testing.T) {
+	nw, err := newNetwork(3, 5)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	errs, err := nw.keyGen()
+	if err != nil || len(errs) != 0 {
+		t.Fatalf("%v %v", err, errs)
+	}
+	pk := nw.participants[1].Group.XonlyPK()
+	for i := 2; i <= 5; i++ {
+		if string(nw.participants[i].Group.XonlyPK()) != string(pk) {
+			t.Fatalf("the group public key of %d is different", i)
+		}
+	}
+	for _, signers := range [][]int{{1, 2, 3}, {5, 3, 1}, {2, 4, 5}, {1, 2, 3, 4, 5}} {
+		m := make([]byte, 32)
+		rand.Read(m)
+		sig, err := nw.sign(signers, m, nil)
+		if err != nil {
+			t.Errorf("%v : %v", signers, err)
+			continue
+		}
+		err = schnorr.Verify(pk, m, sig)
+		if err != nil {
+			t.Errorf("%v : %v", signers, err)
+			continue
+		}
+		t.Logf("%v : %x", signers, sig)
+	}
+	// less than the threshold
+	m := make([]byte, 32)
+	_, err = nw.sign([]int{1, 2}, m, nil)
+	if err == nil {
+		t.Errorf("signing must fail with less than the threshold")
+	}
+	// the nonces are used only once
+	p := nw.participants[1]
+	com, err := p.Commit()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	coms := []*frost.Commitment{com}
+	for _, i := range []int{2, 3} {
+		c, err := nw.participants[i].Commit()
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		coms = append(coms, c)
+	}
+	_, err = p.Sign(m, coms)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	_, err = p.Sign(m, coms)
+	if err == nil {
+		t.Errorf("the nonces must not be reused")
+	}
+}
+
+func TestDKGAbort(t *testing.T) {
+	// invalid proof of knowledge of the participant 2
+	nw, err := newNetwork(2, 3)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	nw.broadcast = func(pkgs []*frost.Round1Package) {
+		pkgs[1].Mu = new(big.Int).Add(pkgs[1].Mu, big.NewInt(1))
+	}
+	errs, err := nw.keyGen()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	for i := 1; i <= 3; i++ {
+		var abort *frost.AbortError
+		if !errors.As(errs[i], &abort) || abort.Participant != 2 {
+			t.Errorf("%d : participant 2 must be identified / %v", i, errs[i])
+		}
+	}
+	// the package of the participant 2 replayed from the other session
+	other, err := frost.NewParticipant(2, 2, 3)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	replayed, err := other.Round1([]byte("other session"))
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	nw, err = newNetwork(2, 3)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	nw.broadcast = func(pkgs []*frost.Round1Package) {
+		pkgs[1] = replayed
+	}
+	errs, err = nw.keyGen()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	for i := 1; i <= 3; i++ {
+		var abort *frost.AbortError
+		if !errors.As(errs[i], &abort) || abort.Participant != 2 {
+			t.Errorf("%d : replayed participant 2 must be identified / %v", i, errs[i])
+		}
+	}
+	// invalid secret share from the participant 3 to the participant 1
+	nw, err = newNetwork(2, 3)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	nw.send = func(l, i int, share *big.Int) *big.Int {
+		if l == 3 && i == 1 {
+			return new(big.Int).Add(share, big.NewInt(1))
+		}
+		return share
+	}
+	errs, err = nw.keyGen()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	var abort *frost.AbortError
+	if len(errs) != 1 || !errors.As(errs[1], &abort) || abort.Participant != 3 {
+		t.Errorf("participant 3 must be identified / %v", errs)
+	}
+	t.Logf("%v", errs)
+}
+
+func TestSignAbort(t *testing.T) {
+	nw, err := newNetwork(3, 4)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	errs, err := nw.keyGen()
+	if err != nil || len(errs) != 0 {
+		t.Fatalf("%v %v", err, errs)
+	}
+	m := make([]byte, 32)
+	rand.Read(m)
+	_, err = nw.sign([]int{1, 3, 4}, m, func(i int, z *big.Int) *big.Int {
+		if i == 3 {
+			return new(big.Int).Add(z, big.NewInt(1))
+		}
+		return z
+	})
+	var abort *frost.AbortError
+	if !errors.As(err, &abort) || abort.Participant != 3 {
+		t.Errorf("participant 3 must be identified / %v", err)
+	}
+	t.Logf("%v", err)
+	// the list of the commitments with the missing commitment
+	coms := []*frost.Commitment{}
+	for _, i := range []int{1, 2, 3} {
+		com, err := nw.participants[i].Commit()
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		coms = append(coms, com)
+	}
+	coms[1] = nil
+	_, err = nw.participants[1].Sign(m, coms)
+	if !errors.As(err, &abort) {
+		t.Errorf("Sign must abort / %v", err)
+	}
+	_, err = nw.participants[1].Group.Aggregate(m, coms, map[int]*big.Int{})
+	if !errors.As(err, &abort) {
+		t.Errorf("Aggregate must abort / %v", err)
+	}
+	// the missing commitment after the commitment of the signer
+	coms[1], coms[2] = coms[2], nil
+	_, err = nw.participants[3].Sign(m, coms)
+	if !errors.As(err, &abort) {
+		t.Errorf("Sign must abort / %v", err)
+	}
+}
@@ -0,0 +1,619 @@
+package musig2
+
+// https://github.com/bitcoin/bips/blob/master/bip-0327.mediawiki
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/tnakagawa/goref/ec"
+	"github.com/tnakagawa/goref/schnorr"
+)
+
+// The constant n refers to the curve order, 0xFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEBAAEDCE6AF48A03BBFD25E8CD0364141.
+var n, _ = new(big.Int).SetString("FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEBAAEDCE6AF48A03BBFD25E8CD0364141", 16)
+
+// InvalidContributionError identifies the signer who provided the invalid contribution.
+// Signer is -1 if the contribution is the aggregate nonce.
+type InvalidContributionError struct {
+	Signer  int
+	Contrib string
+}
+
+func (e *InvalidContributionError) Error() string {
+	if e.Signer < 0 {
+		return fmt.Sprintf("invalid contribution : %s", e.Contrib)
+	}
+	return fmt.Sprintf("invalid contribution : signer %d : %s", e.Signer, e.Contrib)
+}
+
+// The function bytes(n, x), where x is a non-negative integer, returns the n-byte encoding of x, most significant byte first.
+func bytes32(x *big.Int) []byte {
+	bs := make([]byte, 32)
+	return x.FillBytes(bs)
+}
+
+// The function xbytes(P), where P is a point for which not is_infinite(P), returns bytes(32, x(P)).
+func xbytes(P *ec.Point) []byte {
+	return bytes32(P.X)
+}
+
+// The function cbytes(P), where P is a point for which not is_infinite(P),
+// returns a || xbytes(P) where a is a byte that is 2 if has_even_y(P) and 3 otherwise.
+func cbytes(P *ec.Point) []byte {
+	return P.Compressed()
+}
+
+// The function cbytes_ext(P), where P is a point,
+// returns bytes(33, 0) if is_infinite(P). Otherwise, it returns cbytes(P).
+func cbytesExt(P *ec.Point) []byte {
+	if P.Infinite() {
+		return make([]byte, 33)
+	}
+	return cbytes(P)
+}
+
+// The function cpoint(x), where x is a 33-byte array (compressed serialization),
+// sets P = lift_x(int(x[1:33])) and fails if that fails.
+// If x[0] = 2 it returns P and if x[0] = 3 it returns -P. Otherwise, it fails.
+func cpoint(x []byte) (*ec.Point, error) {
+	if len(x) != 33 {
+		return nil, fmt.Errorf("illegal point size")
+	}
+	P, err := schnorr.LiftX(new(big.Int).SetBytes(x[1:33]))
+	if err != nil {
+		return nil, err
+	}
+	if x[0] == 2 {
+		return P, nil
+	}
+	if x[0] == 3 {
+		return ec.Neg(P), nil
+	}
+	return nil, fmt.Errorf("illegal point format")
+}
+
+// The function cpoint_ext(x), where x is a 33-byte array (compressed serialization),
+// returns the point at infinity if x = bytes(33, 0). Otherwise, it returns cpoint(x) and fails if that fails.
+func cpointExt(x []byte) (*ec.Point, error) {
+	if bytes.Equal(x, make([]byte, 33)) {
+		return &ec.Point{}, nil
+	}
+	return cpoint(x)
+}
+
+// The function has_even_y(P), where P is a point for which not is_infinite(P), returns y(P) mod 2 = 0.
+func hasEvenY(P *ec.Point) bool {
+	return P.Y.Bit(0) == 0
+}
+
+// The function hash_tag(x), where x is a byte array,
+// returns the 32-byte hash SHA256(SHA256(tag) || SHA256(tag) || x).
+func hash(tag string, xs ...[]byte) []byte {
+	return schnorr.TaggedHash(tag, bytes.Join(xs, nil))
+}
+
+// scalar returns the integer of the 32-byte array x, and fails if x ≥ n.
+func scalar(x []byte) (*big.Int, error) {
+	if len(x) != 32 {
+		return nil, fmt.Errorf("illegal scalar size")
+	}
+	s := new(big.Int).SetBytes(x)
+	if s.Cmp(n) >= 0 {
+		return nil, fmt.Errorf("scalar ≥ n")
+	}
+	return s, nil
+}
+
+// KeySort : Key Sorting
+// Return pk1..u sorted in lexicographical order.
+func KeySort(pk [][]byte) [][]byte {
+	sorted := make([][]byte, len(pk))
+	copy(sorted, pk)
+	sort.Slice(sorted, func(i, j int) bool {
+		return bytes.Compare(sorted[i], sorted[j]) < 0
+	})
+	return sorted
+}
+
+// KeyAggContext is the key aggregation context (Q, gacc, tacc).
+type KeyAggContext struct {
+	Q    *ec.Point // the point representing the potentially tweaked aggregate public key
+	gacc *big.Int  // the accumulated sign of the tweaks
+	tacc *big.Int  // the accumulated tweak
+}
+
+// XonlyPK : GetXonlyPK(keyagg_ctx)
+// Return xbytes(Q).
+func (ctx *KeyAggContext) XonlyPK() []byte {
+	return xbytes(ctx.Q)
+}
+
+// PlainPK : GetPlainPK(keyagg_ctx)
+// Return cbytes(Q).
+func (ctx *KeyAggContext) PlainPK() []byte {
+	return cbytes(ctx.Q)
+}
+
+// KeyAgg : Key Aggregation
+// The number u of public keys with 0 < u < 2^32
+// The public keys pk1..u: u 33-byte arrays
+func KeyAgg(pk [][]byte) (*KeyAggContext, error) {
+	if len(pk) == 0 {
+		return nil, fmt.Errorf("no public keys")
+	}
+	// Let pk2 = GetSecondKey(pk1..u)
+	pk2 := getSecondKey(pk)
+	Q := &ec.Point{}
+	// For i = 1 .. u:
+	for i := range pk {
+		// Let Pi = cpoint(pki); fail if that fails and blame signer i for invalid individual public key.
+		P, err := cpoint(pk[i])
+		if err != nil {
+			return nil, &InvalidContributionError{Signer: i, Contrib: "pubkey"}
+		}
+		// Let ai = KeyAggCoeffInternal(pk1..u, pki, pk2).
+		a := keyAggCoeffInternal(pk, pk[i], pk2)
+		// Let Q = a1⋅P1 + a2⋅P2 + ... + au⋅Pu
+		Q = ec.Add(Q, ec.Mul(a, P))
+	}
+	// Fail if is_infinite(Q).
+	if Q.Infinite() {
+		return nil, fmt.Errorf("infinite(Q)")
+	}
+	// Let gacc = 1
+	// Let tacc = 0
+	// Return keyagg_ctx = (Q, gacc, tacc).
+	return &KeyAggContext{Q: Q, gacc: big.NewInt(1), tacc: big.NewInt(0)}, nil
+}
+
+// HashKeys(pk1..u)
+// Return hash_KeyAgg list(pk1 || pk2 || ... || pku)
+func hashKeys(pk [][]byte) []byte {
+	return hash("KeyAgg list", pk...)
+}
+
+// GetSecondKey(pk1..u)
+// For j = 1 .. u:
+// If pkj ≠ pk1: Return pkj
+// Return bytes(33, 0)
+func getSecondKey(pk [][]byte) []byte {
+	for j := range pk {
+		if !bytes.Equal(pk[j], pk[0]) {
+			return pk[j]
+		}
+	}
+	return make([]byte, 33)
+}
+
+// KeyAggCoeff(pk1..u, pk')
+// Let pk2 = GetSecondKey(pk1..u):
+// Return KeyAggCoeffInternal(pk1..u, pk', pk2)
+func keyAggCoeff(pk [][]byte, pkd []byte) *big.Int {
+	return keyAggCoeffInternal(pk, pkd, getSecondKey(pk))
+}
+
+// KeyAggCoeffInternal(pk1..u, pk', pk2)
+func keyAggCoeffInternal(pk [][]byte, pkd, pk2 []byte) *big.Int {
+	// Let L = HashKeys(pk1..u)
+	L := hashKeys(pk)
+	// If pk' = pk2: Return 1
+	if bytes.Equal(pkd, pk2) {
+		return big.NewInt(1)
+	}
+	// Return int(hash_KeyAgg coefficient(L || pk')) mod n
+	a := new(big.Int).SetBytes(hash("KeyAgg coefficient", L, pkd))
+	return a.Mod(a, n)
+}
+
+// ApplyTweak : Tweaking the Aggregate Public Key
+// The tweak: a 32-byte array
+// The tweak mode is_xonly_t: a boolean
+func (ctx *KeyAggContext) ApplyTweak(tweak []byte, isXonly bool) (*KeyAggContext, error) {
+	if len(tweak) != 32 {
+		return nil, fmt.Errorf("illegal tweak size")
+	}
+	// If is_xonly_t and not has_even_y(Q): Let g = -1 mod n
+	// Else: Let g = 1
+	g := big.NewInt(1)
+	if isXonly && !hasEvenY(ctx.Q) {
+		g = new(big.Int).Sub(n, big.NewInt(1))
+	}
+	// Let t = int(tweak); fail if t ≥ n
+	t := new(big.Int).SetBytes(tweak)
+	if t.Cmp(n) >= 0 {
+		return nil, fmt.Errorf("The tweak must be less than n.")
+	}
+	// Let Q' = g⋅Q + t⋅G
+	Q := ec.Add(ec.Mul(g, ctx.Q), ec.Mul(t, ec.G))
+	// Fail if is_infinite(Q')
+	if Q.Infinite() {
+		return nil, fmt.Errorf("The result of tweaking cannot be infinity.")
+	}
+	// Let gacc' = g⋅gacc mod n
+	gacc := new(big.Int).Mod(new(big.Int).Mul(g, ctx.gacc), n)
+	// Let tacc' = t + g⋅tacc mod n
+	tacc := new(big.Int).Mod(new(big.Int).Add(t, new(big.Int).Mul(g, ctx.tacc)), n)
+	// Return keyagg_ctx' = (Q', gacc', tacc')
+	return &KeyAggContext{Q: Q, gacc: gacc, tacc: tacc}, nil
+}
+
+// NonceGen : Nonce Generation
+// The secret signing key sk: an integer in the range 1..n-1 or nil if not given
+// The individual public key pk: a 33-byte array
+// The x-only aggregate public key aggpk: a 32-byte array or nil if not given
+// The message m: a byte array or nil if not given
+// The auxiliary input extra_in: a byte array or nil if not given
+// The secret nonce secnonce must be used only once and kept secret.
+func NonceGen(sk *big.Int, pk, aggpk, m, extraIn []byte) ([]byte, []byte, error) {
+	// Let rand' be a 32-byte array freshly drawn uniformly at random
+	rnd := make([]byte, 32)
+	_, err := rand.Read(rnd)
+	if err != nil {
+		return nil, nil, err
+	}
+	return NonceGenInternal(rnd, sk, pk, aggpk, m, extraIn)
+}
+
+// NonceGenInternal is NonceGen with the given random rand'.
+// It must not be used except for testing.
+func NonceGenInternal(rnd []byte, sk *big.Int, pk, aggpk, m, extraIn []byte) ([]byte, []byte, error) {
+	if len(rnd) != 32 {
+		return nil, nil, fmt.Errorf("illegal rand' size")
+	}
+	if len(pk) != 33 {
+		return nil, nil, fmt.Errorf("illegal public key size")
+	}
+	// If the optional argument sk is present:
+	// Let rand be the byte-wise xor of sk and hash_MuSig/aux(rand')
+	// Else: Let rand = rand'
+	r := rnd
+	if sk != nil {
+		if sk.Sign() <= 0 || sk.Cmp(n) >= 0 {
+			return nil, nil, fmt.Errorf("secret key value is out of range.")
+		}
+		aux := hash("MuSig/aux", rnd)
+		r = bytes32(sk)
+		for i := range r {
+			r[i] ^= aux[i]
+		}
+	}
+	// If the optional argument aggpk is not present: Let aggpk = empty_bytestring
+	// If the optional argument m is not present: Let m_prefixed = bytes(1, 0)
+	// Else: Let m_prefixed = bytes(1, 1) || bytes(8, len(m)) || m
+	mPrefixed := []byte{0}
+	if m != nil {
+		l := make([]byte, 8)
+		binary.BigEndian.PutUint64(l, uint64(len(m)))
+		mPrefixed = append(append([]byte{1}, l...), m...)
+	}
+	// If the optional argument extra_in is not present: Let extra_in = empty_bytestring
+	le := make([]byte, 4)
+	binary.BigEndian.PutUint32(le, uint32(len(extraIn)))
+	// Let ki = int(hash_MuSig/nonce(rand || bytes(1, len(pk)) || pk || bytes(1, len(aggpk)) || aggpk || m_prefixed || bytes(4, len(extra_in)) || extra_in || bytes(1, i - 1))) mod n for i = 1,2
+	ks := []*big.Int{}
+	for i := 0; i < 2; i++ {
+		k := new(big.Int).SetBytes(hash("MuSig/nonce",
+			r, []byte{byte(len(pk))}, pk, []byte{byte(len(aggpk))}, aggpk, mPrefixed, le, extraIn, []byte{byte(i)}))
+		k.Mod(k, n)
+		// Fail if k1 = 0 or k2 = 0
+		if k.Sign() == 0 {
+			return nil, nil, fmt.Errorf("k%d = 0", i+1)
+		}
+		ks = append(ks, k)
+	}
+	// Let R*1 = k1⋅G, R*2 = k2⋅G
+	// Let pubnonce = cbytes(R*1) || cbytes(R*2)
+	pubnonce := append(cbytes(ec.Mul(ks[0], ec.G)), cbytes(ec.Mul(ks[1], ec.G))...)
+	// Let secnonce = bytes(32, k1) || bytes(32, k2) || pk
+	secnonce := append(append(bytes32(ks[0]), bytes32(ks[1])...), pk...)
+	// Return secnonce and pubnonce
+	return secnonce, pubnonce, nil
+}
+
+// NonceAgg : Nonce Aggregation
+// The number of signers u: an integer with 0 < u < 2^32
+// The public nonces pubnonce1..u: u 66-byte arrays
+func NonceAgg(pubnonce [][]byte) ([]byte, error) {
+	aggnonce := []byte{}
+	// For j = 1 .. 2:
+	for j := 0; j < 2; j++ {
+		R := &ec.Point{}
+		// For i = 1 .. u:
+		for i := range pubnonce {
+			// Let Ri,j = cpoint(pubnoncei[(j-1)*33:j*33]); fail if that fails and blame signer i for invalid pubnonce.
+			if len(pubnonce[i]) != 66 {
+				return nil, &InvalidContributionError{Signer: i, Contrib: "pubnonce"}
+			}
+			P, err := cpoint(pubnonce[i][j*33 : (j+1)*33])
+			if err != nil {
+				return nil, &InvalidContributionError{Signer: i, Contrib: "pubnonce"}
+			}
+			// Let R'j = R1,j + R2,j + ... + Ru,j
+			R = ec.Add(R, P)
+		}
+		aggnonce = append(aggnonce, cbytesExt(R)...)
+	}
+	// Return aggnonce = cbytes_ext(R'1) || cbytes_ext(R'2)
+	return aggnonce, nil
+}
+
+// SessionContext is the session context.
+type SessionContext struct {
+	AggNonce []byte   // The aggregate public nonce aggnonce: a 66-byte array
+	PubKeys  [][]byte // The public keys pk1..u: u 33-byte arrays
+	Tweaks   [][]byte // The tweaks tweak1..v: v 32-byte arrays
+	IsXonly  []bool   // The tweak modes is_xonly_t1..v: v booleans
+	Msg      []byte   // The message m: a byte array
+}
+
+// sessionValues are (Q, gacc, tacc, b, R, e).
+type sessionValues struct {
+	Q    *ec.Point
+	gacc *big.Int
+	tacc *big.Int
+	b    *big.Int
+	R    *ec.Point
+	e    *big.Int
+}
+
+// GetSessionValues(session_ctx)
+func (ctx *SessionContext) values() (*sessionValues, error) {
+	if len(ctx.Tweaks) != len(ctx.IsXonly) {
+		return nil, fmt.Errorf("illegal tweaks size")
+	}
+	// Let keygen_ctx0 = KeyAgg(pk1..u); fail if that fails
+	keyAggCtx, err := KeyAgg(ctx.PubKeys)
+	if err != nil {
+		return nil, err
+	}
+	// For i = 1 .. v: Let keygen_ctxi = ApplyTweak(keygen_ctxi-1, tweaki, is_xonly_ti); fail if that fails
+	for i := range ctx.Tweaks {
+		keyAggCtx, err = keyAggCtx.ApplyTweak(ctx.Tweaks[i], ctx.IsXonly[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+	// Let (Q, gacc, tacc) = keygen_ctxv
+	v := &sessionValues{Q: keyAggCtx.Q, gacc: keyAggCtx.gacc, tacc: keyAggCtx.tacc}
+	if len(ctx.AggNonce) != 66 {
+		return nil, &InvalidContributionError{Signer: -1, Contrib: "aggnonce"}
+	}
+	// Let b = int(hash_MuSig/noncecoef(aggnonce || xbytes(Q) || m)) mod n
+	v.b = new(big.Int).SetBytes(hash("MuSig/noncecoef", ctx.AggNonce, xbytes(v.Q), ctx.Msg))
+	v.b.Mod(v.b, n)
+	// Let R'1 = cpoint_ext(aggnonce[0:33]), R'2 = cpoint_ext(aggnonce[33:66]); fail if that fails and blame nobody
+	R1, err := cpointExt(ctx.AggNonce[0:33])
+	if err != nil {
+		return nil, &InvalidContributionError{Signer: -1, Contrib: "aggnonce"}
+	}
+	R2, err := cpointExt(ctx.AggNonce[33:66])
+	if err != nil {
+		return nil, &InvalidContributionError{Signer: -1, Contrib: "aggnonce"}
+	}
+	// Let R' = R'1 + b⋅R'2
+	R := ec.Add(R1, ec.Mul(v.b, R2))
+	// If is_infinite(R'): Let final nonce R = G
+	// Else: Let final nonce R = R'
+	if R.Infinite() {
+		R = ec.G
+	}
+	v.R = R
+	// Let e = int(hash_BIP0340/challenge((xbytes(R) || xbytes(Q) || m))) mod n
+	v.e = new(big.Int).SetBytes(hash("BIP0340/challenge", xbytes(v.R), xbytes(v.Q), ctx.Msg))
+	v.e.Mod(v.e, n)
+	// Return (Q, gacc, tacc, b, R, e)
+	return v, nil
+}
+
+// GetSessionKeyAggCoeff(session_ctx, P)
+func (ctx *SessionContext) keyAggCoeff(P *ec.Point) (*big.Int, error) {
+	// Let pk = cbytes(P)
+	pk := cbytes(P)
+	// Fail if pk not in pk1..u
+	found := false
+	for _, p := range ctx.PubKeys {
+		if bytes.Equal(p, pk) {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("The signer's pubkey must be included in the list of pubkeys.")
+	}
+	// Return KeyAggCoeff(pk1..u, pk)
+	return keyAggCoeff(ctx.PubKeys, pk), nil
+}
+
+// Sign : Signing
+// The secret nonce secnonce that has never been used as input to Sign before: a 97-byte array
+// The secret key sk: an integer in the range 1..n-1
+// The session_ctx: a SessionContext data structure
+// The first 64 bytes of secnonce are overwritten with zeros to prevent the reuse.
+func Sign(secnonce []byte, sk *big.Int, ctx *SessionContext) ([]byte, error) {
+	if len(secnonce) != 97 {
+		return nil, fmt.Errorf("illegal secnonce size")
+	}
+	// Let (Q, gacc, _, b, R, e) = GetSessionValues(session_ctx); fail if that fails
+	v, err := ctx.values()
+	if err != nil {
+		return nil, err
+	}
+	// Let k1' = int(secnonce[0:32]), k2' = int(secnonce[32:64])
+	k1d := new(big.Int).SetBytes(secnonce[0:32])
+	k2d := new(big.Int).SetBytes(secnonce[32:64])
+	// Fail if ki' = 0 or ki' ≥ n for i = 1..2
+	if k1d.Sign() == 0 || k1d.Cmp(n) >= 0 {
+		return nil, fmt.Errorf("first secnonce value is out of range.")
+	}
+	if k2d.Sign() == 0 || k2d.Cmp(n) >= 0 {
+		return nil, fmt.Errorf("second secnonce value is out of range.")
+	}
+	// Let k1 = k1', k2 = k2' if has_even_y(R), otherwise let k1 = n - k1', k2 = n - k2'
+	k1 := new(big.Int).Set(k1d)
+	k2 := new(big.Int).Set(k2d)
+	if !hasEvenY(v.R) {
+		k1.Sub(n, k1d)
+		k2.Sub(n, k2d)
+	}
+	// Let d' = int(sk)
+	// Fail if d' = 0 or d' ≥ n
+	if sk.Sign() <= 0 || sk.Cmp(n) >= 0 {
+		return nil, fmt.Errorf("secret key value is out of range.")
+	}
+	// Let P = d'⋅G
+	P := ec.Mul(sk, ec.G)
+	// Let pk = cbytes(P)
+	pk := cbytes(P)
+	// Fail if pk ≠ secnonce[64:97]
+	if !bytes.Equal(pk, secnonce[64:97]) {
+		return nil, fmt.Errorf("Public key does not match nonce_gen argument")
+	}
+	// Let a = GetSessionKeyAggCoeff(session_ctx, P); fail if that fails
+	a, err := ctx.keyAggCoeff(P)
+	if err != nil {
+		return nil, err
+	}
+	// Let g = 1 if has_even_y(Q), otherwise let g = -1 mod n
+	g := big.NewInt(1)
+	if !hasEvenY(v.Q) {
+		g = new(big.Int).Sub(n, big.NewInt(1))
+	}
+	// Let d = g⋅gacc⋅d' mod n (See Negation Of The Secret Key When Signing)
+	d := new(big.Int).Mod(new(big.Int).Mul(new(big.Int).Mul(g, v.gacc), sk), n)
+	// Let s = (k1 + b⋅k2 + e⋅a⋅d) mod n
+	s := new(big.Int).Add(k1, new(big.Int).Mul(v.b, k2))
+	s.Add(s, new(big.Int).Mul(new(big.Int).Mul(v.e, a), d))
+	s.Mod(s, n)
+	// Let psig = bytes(32, s)
+	psig := bytes32(s)
+	// Let pubnonce = cbytes(k1'⋅G) || cbytes(k2'⋅G)
+	pubnonce := append(cbytes(ec.Mul(k1d, ec.G)), cbytes(ec.Mul(k2d, ec.G))...)
+	// Let secnonce[0:64] = bytes(64, 0)
+	copy(secnonce[0:64], make([]byte, 64))
+	// If PartialSigVerifyInternal(psig, pubnonce, pk, session_ctx) (see below) returns failure, abort
+	err = partialSigVerifyInternal(psig, pubnonce, pk, ctx)
+	if err != nil {
+		return nil, err
+	}
+	// Return partial signature psig
+	return psig, nil
+}
+
+// PartialSigVerify : Partial Signature Verification
+// The partial signature psig: a 32-byte array
+// The number u of public nonces and public keys with 0 < u < 2^32
+// The public nonces pubnonce1..u: u 66-byte arrays
+// The public keys pk1..u: u 33-byte arrays
+// The number v of tweaks with 0 ≤ v < 2^32
+// The tweaks tweak1..v: v 32-byte arrays
+// The tweak modes is_xonly_t1..v: v booleans
+// The message m: a byte array
+// The index i of the signer in the list of public nonces and public keys with 0 ≤ i < u
+func PartialSigVerify(psig []byte, pubnonce, pk, tweak [][]byte, isXonly []bool, m []byte, i int) error {
+	if len(pubnonce) != len(pk) || i < 0 || i >= len(pk) {
+		return fmt.Errorf("illegal parameters size")
+	}
+	// Let aggnonce = NonceAgg(pubnonce1..u); fail if that fails
+	aggnonce, err := NonceAgg(pubnonce)
+	if err != nil {
+		return err
+	}
+	// Let session_ctx = (aggnonce, pk1..u, tweak1..v, is_xonly_t1..v, m)
+	ctx := &SessionContext{AggNonce: aggnonce, PubKeys: pk, Tweaks: tweak, IsXonly: isXonly, Msg: m}
+	// Run PartialSigVerifyInternal(psig, pubnoncei, pki, session_ctx)
+	return partialSigVerifyInternal(psig, pubnonce[i], pk[i], ctx)
+}
+
+// PartialSigVerifyInternal(psig, pubnonce, pk, session_ctx)
+func partialSigVerifyInternal(psig, pubnonce, pk []byte, ctx *SessionContext) error {
+	// Let (Q, gacc, _, b, R, e) = GetSessionValues(session_ctx); fail if that fails
+	v, err := ctx.values()
+	if err != nil {
+		return err
+	}
+	// Let s = int(psig); fail if s ≥ n
+	s, err := scalar(psig)
+	if err != nil {
+		return err
+	}
+	// Let R*1 = cpoint(pubnonce[0:33]), R*2 = cpoint(pubnonce[33:66])
+	if len(pubnonce) != 66 {
+		return fmt.Errorf("illegal pubnonce size")
+	}
+	R1, err := cpoint(pubnonce[0:33])
+	if err != nil {
+		return err
+	}
+	R2, err := cpoint(pubnonce[33:66])
+	if err != nil {
+		return err
+	}
+	// Let Re*' = R*1 + b⋅R*2
+	Re := ec.Add(R1, ec.Mul(v.b, R2))
+	// Let effective nonce Re* = Re*' if has_even_y(R), otherwise let Re* = -Re*'
+	if !hasEvenY(v.R) {
+		Re = ec.Neg(Re)
+	}
+	// Let g = 1 if has_even_y(Q), otherwise let g = -1 mod n
+	g := big.NewInt(1)
+	if !hasEvenY(v.Q) {
+		g = new(big.Int).Sub(n, big.NewInt(1))
+	}
+	// Let g' = g⋅gacc mod n (See Negation Of The Individual Public Key When Partially Verifying)
+	gd := new(big.Int).Mod(new(big.Int).Mul(g, v.gacc), n)
+	// Let P = g'⋅cpoint(pk); fail if that fails
+	Pk, err := cpoint(pk)
+	if err != nil {
+		return err
+	}
+	P := ec.Mul(gd, Pk)
+	// Let a = GetSessionKeyAggCoeff(session_ctx, cpoint(pk)); fail if that fails
+	a, err := ctx.keyAggCoeff(Pk)
+	if err != nil {
+		return err
+	}
+	// Fail if s⋅G ≠ Re* + e⋅a⋅P
+	left := ec.Mul(s, ec.G)
+	right := ec.Add(Re, ec.Mul(new(big.Int).Mul(v.e, a), P))
+	if left.Infinite() || right.Infinite() || left.X.Cmp(right.X) != 0 || left.Y.Cmp(right.Y) != 0 {
+		return fmt.Errorf("s⋅G ≠ Re* + e⋅a⋅P")
+	}
+	return nil
+}
+
+// PartialSigAgg : Partial Signature Aggregation
+// The number u of signatures with 0 < u < 2^32
+// The partial signatures psig1..u: u 32-byte arrays
+// The session_ctx: a SessionContext data structure
+func PartialSigAgg(psig [][]byte, ctx *SessionContext) ([]byte, error) {
+	// Let (Q, _, tacc, _, R, e) = GetSessionValues(session_ctx); fail if that fails
+	v, err := ctx.values()
+	if err != nil {
+		return nil, err
+	}
+	s := big.NewInt(0)
+	// For i = 1 .. u:
+	for i := range psig {
+		// Let si = int(psigi); fail if si ≥ n and blame signer i for invalid partial signature.
+		si, err := scalar(psig[i])
+		if err != nil {
+			return nil, &InvalidContributionError{Signer: i, Contrib: "psig"}
+		}
+		s.Add(s, si)
+	}
+	// Let g = 1 if has_even_y(Q), otherwise let g = -1 mod n
+	g := big.NewInt(1)
+	if !hasEvenY(v.Q) {
+		g = new(big.Int).Sub(n, big.NewInt(1))
+	}
+	// Let s = s1 + ... + su + e⋅g⋅tacc mod n
+	s.Add(s, new(big.Int).Mul(new(big.Int).Mul(v.e, g), v.tacc))
+	s.Mod(s, n)
+	// Return sig = xbytes(R) || bytes(32, s)
+	return append(xbytes(v.R), bytes32(s)...), nil
+}
@@ -0,0 +1,451 @@
+package musig2_test
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tnakagawa/goref/ec"
+	"github.com/tnakagawa/goref/musig2"
+	"github.com/tnakagawa/goref/schnorr"
+)
+
+type vectorError struct {
+	Type    string `json:"type"`
+	Signer  *int   `json:"signer"`
+	Contrib string `json:"contrib"`
+	Message string `json:"message"`
+}
+
+type testCase struct {
+	KeyIndices    []int        `json:"key_indices"`
+	NonceIndices  []int        `json:"nonce_indices"`
+	PnonceIndices []int        `json:"pnonce_indices"`
+	TweakIndices  []int        `json:"tweak_indices"`
+	PsigIndices   []int        `json:"psig_indices"`
+	IsXonly       []bool       `json:"is_xonly"`
+	AggNonceIndex int          `json:"aggnonce_index"`
+	AggNonce      string       `json:"aggnonce"`
+	MsgIndex      int          `json:"msg_index"`
+	SignerIndex   int          `json:"signer_index"`
+	SecNonceIndex int          `json:"secnonce_index"`
+	Sig           string       `json:"sig"`
+	Expected      string       `json:"expected"`
+	Error         *vectorError `json:"error"`
+	Comment       string       `json:"comment"`
+}
+
+type testVectors struct {
+	Sk            string     `json:"sk"`
+	PubKeys       []string   `json:"pubkeys"`
+	SortedPubKeys []string   `json:"sorted_pubkeys"`
+	SecNonces     []string   `json:"secnonces"`
+	SecNonce      string     `json:"secnonce"`
+	PNonces       []string   `json:"pnonces"`
+	AggNonces     []string   `json:"aggnonces"`
+	AggNonce      string     `json:"aggnonce"`
+	Tweaks        []string   `json:"tweaks"`
+	Psigs         []string   `json:"psigs"`
+	Msgs          []string   `json:"msgs"`
+	Msg           string     `json:"msg"`
+	Valid         []testCase `json:"valid_test_cases"`
+	Errors        []testCase `json:"error_test_cases"`
+	SignErrors    []testCase `json:"sign_error_test_cases"`
+	VerifyFails   []testCase `json:"verify_fail_test_cases"`
+	VerifyErrors  []testCase `json:"verify_error_test_cases"`
+}
+
+func readVectors(t *testing.T, name string) *testVectors {
+	bs, err := ioutil.ReadFile(name)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	var vectors testVectors
+	err = json.Unmarshal(bs, &vectors)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	return &vectors
+}
+
+func h2b(t *testing.T, s string) []byte {
+	bs, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	return bs
+}
+
+func pick(t *testing.T, values []string, indices []int) [][]byte {
+	bss := [][]byte{}
+	for _, i := range indices {
+		bss = append(bss, h2b(t, values[i]))
+	}
+	return bss
+}
+
+// checkError checks that err is the expected error of the test vector.
+func checkError(t *testing.T, i int, expected *vectorError, err error) {
+	if err == nil {
+		t.Errorf("%d : error must occur / %+v", i, expected)
+		return
+	}
+	if expected.Type == "invalid_contribution" {
+		var ice *musig2.InvalidContributionError
+		if !errors.As(err, &ice) {
+			t.Errorf("%d : illegal error %v", i, err)
+			return
+		}
+		signer := -1
+		if expected.Signer != nil {
+			signer = *expected.Signer
+		}
+		if ice.Signer != signer || (expected.Contrib != "" && ice.Contrib != expected.Contrib) {
+			t.Errorf("%d : illegal error %v / %+v", i, err, expected)
+			return
+		}
+	} else if err.Error() != expected.Message {
+		t.Errorf("%d : illegal error %v / %+v", i, err, expected.Message)
+		return
+	}
+	t.Logf("%d : %v", i, err)
+}
+
+func TestKeySort(t *testing.T) {
+	v := readVectors(t, "./key_sort_vectors.json")
+	sorted := musig2.KeySort(pick(t, v.PubKeys, []int{0, 1, 2, 3, 4}))
+	if !reflect.DeepEqual(sorted, pick(t, v.SortedPubKeys, []int{0, 1, 2, 3, 4})) {
+		t.Errorf("KeySort error")
+	}
+}
+
+func TestKeyAgg(t *testing.T) {
+	v := readVectors(t, "./key_agg_vectors.json")
+	for i, c := range v.Valid {
+		ctx, err := musig2.KeyAgg(pick(t, v.PubKeys, c.KeyIndices))
+		if err != nil {
+			t.Errorf("%d : %v", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(ctx.XonlyPK(), h2b(t, c.Expected)) {
+			t.Errorf("%d : %x != %s", i, ctx.XonlyPK(), c.Expected)
+		}
+	}
+	for i, c := range v.Errors {
+		ctx, err := musig2.KeyAgg(pick(t, v.PubKeys, c.KeyIndices))
+		if err == nil {
+			for j, ti := range c.TweakIndices {
+				ctx, err = ctx.ApplyTweak(h2b(t, v.Tweaks[ti]), c.IsXonly[j])
+				if err != nil {
+					break
+				}
+			}
+		}
+		checkError(t, i, c.Error, err)
+	}
+}
+
+func TestNonceGen(t *testing.T) {
+	bs, err := ioutil.ReadFile("./nonce_gen_vectors.json")
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	var vectors struct {
+		TestCases []struct {
+			Rand     string  `json:"rand_"`
+			Sk       *string `json:"sk"`
+			Pk       string  `json:"pk"`
+			AggPk    *string `json:"aggpk"`
+			Msg      *string `json:"msg"`
+			ExtraIn  *string `json:"extra_in"`
+			Expected string  `json:"expected"`
+		} `json:"test_cases"`
+	}
+	err = json.Unmarshal(bs, &vectors)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	// optional returns nil if the value is not present.
+	optional := func(s *string) []byte {
+		if s == nil {
+			return nil
+		}
+		return h2b(t, *s)
+	}
+	for i, c := range vectors.TestCases {
+		var sk *big.Int
+		if c.Sk != nil {
+			sk = new(big.Int).SetBytes(h2b(t, *c.Sk))
+		}
+		secnonce, pubnonce, err := musig2.NonceGenInternal(h2b(t, c.Rand), sk, h2b(t, c.Pk), optional(c.AggPk), optional(c.Msg), optional(c.ExtraIn))
+		if err != nil {
+			t.Errorf("%d : %v", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(secnonce, h2b(t, c.Expected)) {
+			t.Errorf("%d : %x != %s", i, secnonce, c.Expected)
+		}
+		k1 := new(big.Int).SetBytes(secnonce[0:32])
+		k2 := new(big.Int).SetBytes(secnonce[32:64])
+		if !reflect.DeepEqual(pubnonce, append(ec.Mul(k1, ec.G).Compressed(), ec.Mul(k2, ec.G).Compressed()...)) {
+			t.Errorf("%d : illegal pubnonce %x", i, pubnonce)
+		}
+	}
+	// the secret key out of range
+	pk := ec.Mul(big.NewInt(1), ec.G).Compressed()
+	for _, sk := range []*big.Int{big.NewInt(0), new(big.Int).Lsh(big.NewInt(1), 256)} {
+		_, _, err := musig2.NonceGenInternal(make([]byte, 32), sk, pk, nil, nil, nil)
+		if err == nil {
+			t.Errorf("no error %x", sk)
+		}
+	}
+}
+
+func TestNonceAgg(t *testing.T) {
+	v := readVectors(t, "./nonce_agg_vectors.json")
+	for i, c := range v.Valid {
+		aggnonce, err := musig2.NonceAgg(pick(t, v.PNonces, c.PnonceIndices))
+		if err != nil {
+			t.Errorf("%d : %v", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(aggnonce, h2b(t, c.Expected)) {
+			t.Errorf("%d : %x != %s", i, aggnonce, c.Expected)
+		}
+	}
+	for i, c := range v.Errors {
+		_, err := musig2.NonceAgg(pick(t, v.PNonces, c.PnonceIndices))
+		checkError(t, i, c.Error, err)
+	}
+}
+
+func TestSignVerify(t *testing.T) {
+	v := readVectors(t, "./sign_verify_vectors.json")
+	sk := new(big.Int).SetBytes(h2b(t, v.Sk))
+	for i, c := range v.Valid {
+		ctx := &musig2.SessionContext{
+			AggNonce: h2b(t, v.AggNonces[c.AggNonceIndex]),
+			PubKeys:  pick(t, v.PubKeys, c.KeyIndices),
+			Msg:      h2b(t, v.Msgs[c.MsgIndex]),
+		}
+		// The aggregate nonce of the nonces.
+		aggnonce, err := musig2.NonceAgg(pick(t, v.PNonces, c.NonceIndices))
+		if err != nil || !reflect.DeepEqual(aggnonce, ctx.AggNonce) {
+			t.Errorf("%d : illegal aggnonce %x %v", i, aggnonce, err)
+			continue
+		}
+		// Sign overwrites the secret nonce.
+		secnonce := h2b(t, v.SecNonces[0])
+		psig, err := musig2.Sign(secnonce, sk, ctx)
+		if err != nil {
+			t.Errorf("%d : %v", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(psig, h2b(t, c.Expected)) {
+			t.Errorf("%d : %x != %s", i, psig, c.Expected)
+		}
+		err = musig2.PartialSigVerify(psig, pick(t, v.PNonces, c.NonceIndices), ctx.PubKeys, nil, nil, ctx.Msg, c.SignerIndex)
+		if err != nil {
+			t.Errorf("%d : %v", i, err)
+		}
+		_, err = musig2.Sign(secnonce, sk, ctx)
+		if err == nil {
+			t.Errorf("%d : the secret nonce must not be reused", i)
+		}
+	}
+	for i, c := range v.SignErrors {
+		ctx := &musig2.SessionContext{
+			AggNonce: h2b(t, v.AggNonces[c.AggNonceIndex]),
+			PubKeys:  pick(t, v.PubKeys, c.KeyIndices),
+			Msg:      h2b(t, v.Msgs[c.MsgIndex]),
+		}
+		_, err := musig2.Sign(h2b(t, v.SecNonces[c.SecNonceIndex]), sk, ctx)
+		checkError(t, i, c.Error, err)
+	}
+	for i, c := range v.VerifyFails {
+		err := musig2.PartialSigVerify(h2b(t, c.Sig), pick(t, v.PNonces, c.NonceIndices), pick(t, v.PubKeys, c.KeyIndices), nil, nil, h2b(t, v.Msgs[c.MsgIndex]), c.SignerIndex)
+		if err == nil {
+			t.Errorf("%d : verify must fail / %s", i, c.Comment)
+		}
+	}
+	for i, c := range v.VerifyErrors {
+		err := musig2.PartialSigVerify(h2b(t, c.Sig), pick(t, v.PNonces, c.NonceIndices), pick(t, v.PubKeys, c.KeyIndices), nil, nil, h2b(t, v.Msgs[c.MsgIndex]), c.SignerIndex)
+		checkError(t, i, c.Error, err)
+	}
+}
+
+func TestTweak(t *testing.T) {
+	v := readVectors(t, "./tweak_vectors.json")
+	sk := new(big.Int).SetBytes(h2b(t, v.Sk))
+	for i, c := range v.Valid {
+		ctx := &musig2.SessionContext{
+			AggNonce: h2b(t, v.AggNonce),
+			PubKeys:  pick(t, v.PubKeys, c.KeyIndices),
+			Tweaks:   pick(t, v.Tweaks, c.TweakIndices),
+			IsXonly:  c.IsXonly,
+			Msg:      h2b(t, v.Msg),
+		}
+		psig, err := musig2.Sign(h2b(t, v.SecNonce), sk, ctx)
+		if err != nil {
+			t.Errorf("%d : %v", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(psig, h2b(t, c.Expected)) {
+			t.Errorf("%d : %x != %s", i, psig, c.Expected)
+		}
+		err = musig2.PartialSigVerify(psig, pick(t, v.PNonces, c.NonceIndices), ctx.PubKeys, ctx.Tweaks, ctx.IsXonly, ctx.Msg, c.SignerIndex)
+		if err != nil {
+			t.Errorf("%d : %v", i, err)
+		}
+	}
+	for i, c := range v.Errors {
+		ctx := &musig2.SessionContext{
+			AggNonce: h2b(t, v.AggNonce),
+			PubKeys:  pick(t, v.PubKeys, c.KeyIndices),
+			Tweaks:   pick(t, v.Tweaks, c.TweakIndices),
+			IsXonly:  c.IsXonly,
+			Msg:      h2b(t, v.Msg),
+		}
+		_, err := musig2.Sign(h2b(t, v.SecNonce), sk, ctx)
+		checkError(t, i, c.Error, err)
+	}
+}
+
+func TestSigAgg(t *testing.T) {
+	v := readVectors(t, "./sig_agg_vectors.json")
+	for i, c := range v.Valid {
+		ctx := &musig2.SessionContext{
+			AggNonce: h2b(t, c.AggNonce),
+			PubKeys:  pick(t, v.PubKeys, c.KeyIndices),
+			Tweaks:   pick(t, v.Tweaks, c.TweakIndices),
+			IsXonly:  c.IsXonly,
+			Msg:      h2b(t, v.Msg),
+		}
+		aggnonce, err := musig2.NonceAgg(pick(t, v.PNonces, c.NonceIndices))
+		if err != nil || !reflect.DeepEqual(aggnonce, ctx.AggNonce) {
+			t.Errorf("%d : illegal aggnonce %x %v", i, aggnonce, err)
+			continue
+		}
+		sig, err := musig2.PartialSigAgg(pick(t, v.Psigs, c.PsigIndices), ctx)
+		if err != nil {
+			t.Errorf("%d : %v", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(sig, h2b(t, c.Expected)) {
+			t.Errorf("%d : %x != %s", i, sig, c.Expected)
+		}
+		// The aggregate public key with the tweaks.
+		keyAggCtx, err := musig2.KeyAgg(ctx.PubKeys)
+		if err != nil {
+			t.Errorf("%d : %v", i, err)
+			continue
+		}
+		for j := range ctx.Tweaks {
+			keyAggCtx, err = keyAggCtx.ApplyTweak(ctx.Tweaks[j], ctx.IsXonly[j])
+			if err != nil {
+				t.Errorf("%d : %v", i, err)
+				break
+			}
+		}
+		err = schnorr.Verify(keyAggCtx.XonlyPK(), ctx.Msg, sig)
+		if err != nil {
+			t.Errorf("%d : %v", i, err)
+		}
+	}
+	for i, c := range v.Errors {
+		ctx := &musig2.SessionContext{
+			AggNonce: h2b(t, c.AggNonce),
+			PubKeys:  pick(t, v.PubKeys, c.KeyIndices),
+			Tweaks:   pick(t, v.Tweaks, c.TweakIndices),
+			IsXonly:  c.IsXonly,
+			Msg:      h2b(t, v.Msg),
+		}
+		_, err := musig2.PartialSigAgg(pick(t, v.Psigs, c.PsigIndices), ctx)
+		checkError(t, i, c.Error, err)
+	}
+}
+
+func TestMuSig2(t *testing.T) {
+	n, _ := new(big.Int).SetString("FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEBAAEDCE6AF48A03BBFD25E8CD0364141", 16)
+	u := 3
+	sks := []*big.Int{}
+	pks := [][]byte{}
+	for i := 0; i < u; i++ {
+		sk, err := rand.Int(rand.Reader, new(big.Int).Sub(n, big.NewInt(1)))
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		sk.Add(sk, big.NewInt(1))
+		sks = append(sks, sk)
+		pks = append(pks, ec.Mul(sk, ec.G).Compressed())
+	}
+	pks = musig2.KeySort(pks)
+	keyAggCtx, err := musig2.KeyAgg(pks)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	// x-only tweak such as Taproot
+	tweak := make([]byte, 32)
+	rand.Read(tweak)
+	keyAggCtx, err = keyAggCtx.ApplyTweak(tweak, true)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	m := make([]byte, 32)
+	rand.Read(m)
+	secnonces := [][]byte{}
+	pubnonces := [][]byte{}
+	signers := []int{}
+	for i := 0; i < u; i++ {
+		pk := ec.Mul(sks[i], ec.G).Compressed()
+		secnonce, pubnonce, err := musig2.NonceGen(sks[i], pk, keyAggCtx.XonlyPK(), m, nil)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		secnonces = append(secnonces, secnonce)
+		pubnonces = append(pubnonces, pubnonce)
+		for j := range pks {
+			if strings.EqualFold(hex.EncodeToString(pks[j]), hex.EncodeToString(pk)) {
+				signers = append(signers, j)
+			}
+		}
+	}
+	aggnonce, err := musig2.NonceAgg(pubnonces)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	ctx := &musig2.SessionContext{AggNonce: aggnonce, PubKeys: pks, Tweaks: [][]byte{tweak}, IsXonly: []bool{true}, Msg: m}
+	psigs := [][]byte{}
+	for i := 0; i < u; i++ {
+		psig, err := musig2.Sign(secnonces[i], sks[i], ctx)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		psigs = append(psigs, psig)
+	}
+	// The order of the public nonces follows the order of the public keys.
+	ordered := make([][]byte, u)
+	for i := 0; i < u; i++ {
+		ordered[signers[i]] = pubnonces[i]
+	}
+	for i := 0; i < u; i++ {
+		err = musig2.PartialSigVerify(psigs[i], ordered, pks, ctx.Tweaks, ctx.IsXonly, m, signers[i])
+		if err != nil {
+			t.Errorf("%d : %v", i, err)
+		}
+	}
+	sig, err := musig2.PartialSigAgg(psigs, ctx)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	err = schnorr.Verify(keyAggCtx.XonlyPK(), m, sig)
+	if err != nil {
+		t.Errorf("%v", err)
+	}
+}
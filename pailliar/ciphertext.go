@@ -0,0 +1,212 @@
+package pailliar
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+)
+
+// Ciphertext is an encrypted data with the public key,
+// the homomorphic operations are checked to use the same public key.
+// The plaintexts are in Z_n, so that the results of the operations are modulo n.
+type Ciphertext struct {
+	pub *PublicKey
+	c   *big.Int
+}
+
+// NewCiphertext returns the ciphertext of c encrypted by the public key.
+func (pub *PublicKey) NewCiphertext(c *big.Int) (*Ciphertext, error) {
+	// c in Z*_{n^2}
+	if c == nil || c.Cmp(ZERO) <= 0 || c.Cmp(pub.n2) >= 0 || GCD(c, pub.n).Cmp(ONE) != 0 {
+		return nil, fmt.Errorf("c is not in Z*_{n^2}")
+	}
+	return &Ciphertext{pub: pub, c: new(big.Int).Set(c)}, nil
+}
+
+// Encrypt returns the ciphertext of the plaintext m, where m is reduced modulo n.
+func (pub *PublicKey) Encrypt(m *big.Int) (*Ciphertext, error) {
+	c, err := pub.Encryption(new(big.Int).Mod(m, pub.n))
+	if err != nil {
+		return nil, err
+	}
+	return &Ciphertext{pub: pub, c: c}, nil
+}
+
+// Decrypt returns the decrypted data of the ciphertext.
+func (pri *PrivateKey) Decrypt(ct *Ciphertext) (*big.Int, error) {
+	if ct.pub.n.Cmp(pri.n) != 0 || ct.pub.g.Cmp(pri.g) != 0 {
+		return nil, fmt.Errorf("the public key is different")
+	}
+	return pri.Decryption(ct.c)
+}
+
+// C returns the encrypted data c.
+func (ct *Ciphertext) C() *big.Int {
+	return new(big.Int).Set(ct.c)
+}
+
+// PublicKey returns the public key of the ciphertext.
+func (ct *Ciphertext) PublicKey() *PublicKey {
+	return ct.pub
+}
+
+// check returns an error if the ciphertexts have the different public keys.
+func (ct *Ciphertext) check(o *Ciphertext) error {
+	if ct.pub != o.pub && (ct.pub.n.Cmp(o.pub.n) != 0 || ct.pub.g.Cmp(o.pub.g) != 0) {
+		return fmt.Errorf("the public keys are different")
+	}
+	return nil
+}
+
+// Add returns the ciphertext of m1 + m2 mod n.
+func (ct *Ciphertext) Add(o *Ciphertext) (*Ciphertext, error) {
+	err := ct.check(o)
+	if err != nil {
+		return nil, err
+	}
+	// c1 * c2 -> m1 + m2
+	return &Ciphertext{pub: ct.pub, c: ct.pub.Mul(ct.c, o.c)}, nil
+}
+
+// Sub returns the ciphertext of m1 - m2 mod n.
+func (ct *Ciphertext) Sub(o *Ciphertext) (*Ciphertext, error) {
+	return ct.Add(o.Neg())
+}
+
+// AddPlain returns the ciphertext of m1 + m2 mod n for the plaintext m2.
+func (ct *Ciphertext) AddPlain(m *big.Int) *Ciphertext {
+	// c * g^m2 -> m1 + m2
+	gm := ct.pub.gm(new(big.Int).Mod(m, ct.pub.n))
+	return &Ciphertext{pub: ct.pub, c: ct.pub.Mul(ct.c, gm)}
+}
+
+// MulPlain returns the ciphertext of m1 * k mod n for the plaintext k.
+func (ct *Ciphertext) MulPlain(k *big.Int) *Ciphertext {
+	// c ^ k -> m1 * k
+	return &Ciphertext{pub: ct.pub, c: ct.pub.Exp(ct.c, new(big.Int).Mod(k, ct.pub.n))}
+}
+
+// Neg returns the ciphertext of -m mod n.
+func (ct *Ciphertext) Neg() *Ciphertext {
+	// c^-1 -> -m
+	return &Ciphertext{pub: ct.pub, c: new(big.Int).ModInverse(ct.c, ct.pub.n2)}
+}
+
+// Rerandomize returns the new ciphertext of the same plaintext,
+// which is unlinkable to the original ciphertext.
+func (ct *Ciphertext) Rerandomize() *Ciphertext {
+	// c * r^n -> m
+	return &Ciphertext{pub: ct.pub, c: ct.pub.Mul(ct.c, ct.pub.NewRn())}
+}
+
+// Encoder maps the signed integers and the fixed-point decimals into Z_n and back.
+// A signed integer x with |x| < n/2 is encoded as x mod n,
+// so that the plaintexts in [0, n/2] are non-negative and the plaintexts in (n/2, n) are negative.
+// A decimal x is encoded as the signed integer round(x * 10^Precision).
+//
+// The homomorphic addition and the multiplication by an integer keep the precision,
+// but the multiplication of two fixed-point decimals doubles the precision,
+// so that the result must be decoded by the encoder with the twice precision.
+// The results overflow silently if they are not less than n/2 in absolute value.
+type Encoder struct {
+	n         *big.Int // n
+	half      *big.Int // n / 2
+	precision int      // the number of the fractional digits
+	scale     *big.Int // 10^precision
+}
+
+// NewEncoder returns the encoder of the modulus n with the number of the fractional digits.
+func NewEncoder(n *big.Int, precision int) (*Encoder, error) {
+	if n == nil || n.Cmp(big.NewInt(2)) <= 0 {
+		return nil, fmt.Errorf("illegal n")
+	}
+	if precision < 0 {
+		return nil, fmt.Errorf("illegal precision %d", precision)
+	}
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(precision)), nil)
+	return &Encoder{n: new(big.Int).Set(n), half: new(big.Int).Rsh(n, 1), precision: precision, scale: scale}, nil
+}
+
+// Precision returns the number of the fractional digits.
+func (e *Encoder) Precision() int {
+	return e.precision
+}
+
+// Encode returns the plaintext of the signed integer x, where |x| ≤ n/2.
+func (e *Encoder) Encode(x *big.Int) (*big.Int, error) {
+	if new(big.Int).Abs(x).Cmp(e.half) > 0 {
+		return nil, fmt.Errorf("x is out of range")
+	}
+	return new(big.Int).Mod(x, e.n), nil
+}
+
+// Decode returns the signed integer of the plaintext m.
+func (e *Encoder) Decode(m *big.Int) *big.Int {
+	x := new(big.Int).Mod(m, e.n)
+	if x.Cmp(e.half) > 0 {
+		x.Sub(x, e.n)
+	}
+	return x
+}
+
+// EncodeInt64 returns the plaintext of the integer x without the scaling.
+func (e *Encoder) EncodeInt64(x int64) (*big.Int, error) {
+	return e.Encode(big.NewInt(x))
+}
+
+// DecodeInt64 returns the integer of the plaintext m without the scaling.
+func (e *Encoder) DecodeInt64(m *big.Int) (int64, error) {
+	x := e.Decode(m)
+	if !x.IsInt64() {
+		return 0, fmt.Errorf("x overflows int64")
+	}
+	return x.Int64(), nil
+}
+
+// EncodeRat returns the plaintext of the decimal x, which is rounded half away from zero to the precision.
+func (e *Encoder) EncodeRat(x *big.Rat) (*big.Int, error) {
+	// round(x * 10^precision)
+	y := new(big.Rat).Mul(x, new(big.Rat).SetInt(e.scale))
+	num := new(big.Int).Abs(y.Num())
+	q, r := new(big.Int).QuoRem(num, y.Denom(), new(big.Int))
+	if r.Lsh(r, 1).Cmp(y.Denom()) >= 0 {
+		q.Add(q, ONE)
+	}
+	if y.Sign() < 0 {
+		q.Neg(q)
+	}
+	return e.Encode(q)
+}
+
+// DecodeRat returns the decimal of the plaintext m.
+func (e *Encoder) DecodeRat(m *big.Int) *big.Rat {
+	return new(big.Rat).SetFrac(e.Decode(m), e.scale)
+}
+
+// EncodeFixed returns the plaintext of the decimal string like "-123.45".
+func (e *Encoder) EncodeFixed(s string) (*big.Int, error) {
+	x, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return nil, fmt.Errorf("illegal decimal %q", s)
+	}
+	return e.EncodeRat(x)
+}
+
+// DecodeFixed returns the decimal string of the plaintext m with the precision.
+func (e *Encoder) DecodeFixed(m *big.Int) string {
+	return e.DecodeRat(m).FloatString(e.precision)
+}
+
+// EncodeFloat64 returns the plaintext of the float x.
+func (e *Encoder) EncodeFloat64(x float64) (*big.Int, error) {
+	if math.IsNaN(x) || math.IsInf(x, 0) {
+		return nil, fmt.Errorf("x is not finite")
+	}
+	return e.EncodeRat(new(big.Rat).SetFloat64(x))
+}
+
+// DecodeFloat64 returns the nearest float of the plaintext m.
+func (e *Encoder) DecodeFloat64(m *big.Int) float64 {
+	x, _ := e.DecodeRat(m).Float64()
+	return x
+}
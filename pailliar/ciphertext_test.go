@@ -0,0 +1,200 @@
+package pailliar_test
+
+import (
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/tnakagawa/goref/pailliar"
+)
+
+func TestCiphertext(t *testing.T) {
+	pub, pri, err := pailliar.KeyGeneration(512)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	enc, err := pailliar.NewEncoder(pub.N(), 0)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	encrypt := func(x int64) *pailliar.Ciphertext {
+		m, err := enc.EncodeInt64(x)
+		if err != nil {
+			t.Fatalf("error %v", err)
+		}
+		ct, err := pub.Encrypt(m)
+		if err != nil {
+			t.Fatalf("error %v", err)
+		}
+		return ct
+	}
+	decrypt := func(ct *pailliar.Ciphertext) int64 {
+		m, err := pri.Decrypt(ct)
+		if err != nil {
+			t.Fatalf("error %v", err)
+		}
+		x, err := enc.DecodeInt64(m)
+		if err != nil {
+			t.Fatalf("error %v", err)
+		}
+		return x
+	}
+	a, b := encrypt(-1234), encrypt(5678)
+	add, err := a.Add(b)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	sub, err := a.Sub(b)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	rr := a.Rerandomize()
+	if rr.C().Cmp(a.C()) == 0 {
+		t.Errorf("Rerandomize : c is not changed")
+	}
+	tests := []struct {
+		name string
+		ct   *pailliar.Ciphertext
+		want int64
+	}{
+		{"a", a, -1234},
+		{"a + b", add, 4444},
+		{"a - b", sub, -6912},
+		{"a + 100", a.AddPlain(big.NewInt(100)), -1134},
+		{"a + -100", a.AddPlain(big.NewInt(-100)), -1334},
+		{"a * 3", a.MulPlain(big.NewInt(3)), -3702},
+		{"a * -3", a.MulPlain(big.NewInt(-3)), 3702},
+		{"-a", a.Neg(), 1234},
+		{"-(-a)", a.Neg().Neg(), -1234},
+		{"rerandomize(a)", rr, -1234},
+		{"max int64", encrypt(math.MaxInt64), math.MaxInt64},
+		{"min int64", encrypt(math.MinInt64), math.MinInt64},
+	}
+	for _, test := range tests {
+		x := decrypt(test.ct)
+		if x != test.want {
+			t.Errorf("%s : %d != %d", test.name, x, test.want)
+		}
+	}
+	// the other key
+	pub2, _, err := pailliar.KeyGeneration(512)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	c, err := pub2.Encrypt(big.NewInt(1))
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	_, err = a.Add(c)
+	if err == nil {
+		t.Errorf("other key : Add must fail")
+	}
+	_, err = pri.Decrypt(c)
+	if err == nil {
+		t.Errorf("other key : Decrypt must fail")
+	}
+	// raw ciphertext
+	ct, err := pub.NewCiphertext(a.C())
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	if decrypt(ct) != -1234 {
+		t.Errorf("NewCiphertext : %d != -1234", decrypt(ct))
+	}
+	_, err = pub.NewCiphertext(pub.N())
+	if err == nil {
+		t.Errorf("c = n : NewCiphertext must fail")
+	}
+	// int64 overflow
+	m, _ := enc.EncodeInt64(math.MaxInt64)
+	_, err = enc.DecodeInt64(new(big.Int).Add(m, big.NewInt(1)))
+	if err == nil {
+		t.Errorf("overflow : DecodeInt64 must fail")
+	}
+}
+
+func TestFixedPoint(t *testing.T) {
+	pub, pri, err := pailliar.KeyGeneration(512)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	enc, err := pailliar.NewEncoder(pub.N(), 4)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	tests := []struct {
+		in, out string
+	}{
+		{"0", "0.0000"},
+		{"12.5", "12.5000"},
+		{"-12.5", "-12.5000"},
+		{"3.14159", "3.1416"},
+		{"-3.14155", "-3.1416"},
+		{"0.00004", "0.0000"},
+		{"123456789012345678901234567890.1234", "123456789012345678901234567890.1234"},
+	}
+	for _, test := range tests {
+		m, err := enc.EncodeFixed(test.in)
+		if err != nil {
+			t.Fatalf("%s : error %v", test.in, err)
+		}
+		ct, err := pub.Encrypt(m)
+		if err != nil {
+			t.Fatalf("error %v", err)
+		}
+		x, err := pri.Decrypt(ct)
+		if err != nil {
+			t.Fatalf("error %v", err)
+		}
+		if out := enc.DecodeFixed(x); out != test.out {
+			t.Errorf("%s : %s != %s", test.in, out, test.out)
+		}
+	}
+	// sum and average of the encrypted values
+	values := []float64{1.25, -0.5, 10.125, 3.0}
+	var sum *pailliar.Ciphertext
+	for _, v := range values {
+		m, err := enc.EncodeFloat64(v)
+		if err != nil {
+			t.Fatalf("error %v", err)
+		}
+		ct, err := pub.Encrypt(m)
+		if err != nil {
+			t.Fatalf("error %v", err)
+		}
+		if sum == nil {
+			sum = ct
+		} else if sum, err = sum.Add(ct); err != nil {
+			t.Fatalf("error %v", err)
+		}
+	}
+	x, err := pri.Decrypt(sum)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	if f := enc.DecodeFloat64(x); f != 13.875 {
+		t.Errorf("sum : %v != 13.875", f)
+	}
+	// the product of two fixed-point decimals has the twice precision
+	k, _ := enc.EncodeFixed("-0.25")
+	x, err = pri.Decrypt(sum.MulPlain(k))
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	enc2, _ := pailliar.NewEncoder(pub.N(), 8)
+	if out := enc2.DecodeFixed(x); out != "-3.46875000" {
+		t.Errorf("product : %s != -3.46875000", out)
+	}
+	_, err = enc.EncodeFloat64(math.NaN())
+	if err == nil {
+		t.Errorf("NaN : EncodeFloat64 must fail")
+	}
+	_, err = enc.EncodeFixed("1.2.3")
+	if err == nil {
+		t.Errorf("illegal decimal : EncodeFixed must fail")
+	}
+	_, err = enc.Encode(pub.N())
+	if err == nil {
+		t.Errorf("x = n : Encode must fail")
+	}
+}
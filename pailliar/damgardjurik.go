@@ -0,0 +1,199 @@
+package pailliar
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// The Damgård–Jurik generalization of pailliar cipher with the parameter s ≥ 1,
+// the plaintexts are in Z_n^s and the ciphertexts are in Z*_n^(s+1),
+// so that a ciphertext of (s+1)|n| bits carries s|n| bits of the plaintext.
+// The keys are the same as pailliar cipher, and s = 1 is pailliar cipher with g = n + 1.
+//
+// A Generalisation, a Simplification and Some Applications of Paillier's Probabilistic Public-Key System
+// (Damgård, Jurik, https://doi.org/10.1007/3-540-44586-2_9)
+//
+//	Encryption : c = (1 + n)^m * r^(n^s) mod n^(s+1)
+//	Decryption : c^λ = (1 + n)^(mλ mod n^s) mod n^(s+1) , m = (mλ mod n^s) * λ^-1 mod n^s
+
+// DJPublicKey is a Damgård–Jurik public key.
+type DJPublicKey struct {
+	s   int      // s
+	n   *big.Int // n
+	ns  *big.Int // n^s
+	ns1 *big.Int // n^(s+1)
+}
+
+// DJPrivateKey is a Damgård–Jurik private key.
+type DJPrivateKey struct {
+	DJPublicKey
+	lam    *big.Int // λ = lcm(p-1,q-1)
+	lamInv *big.Int // λ^-1 mod n^s
+}
+
+// DamgardJurik returns the Damgård–Jurik public key of the public key with the parameter s.
+func (pub *PublicKey) DamgardJurik(s int) (*DJPublicKey, error) {
+	return newDJPublicKey(pub.n, s)
+}
+
+// newDJPublicKey returns the Damgård–Jurik public key of the modulus n with the parameter s.
+func newDJPublicKey(n *big.Int, s int) (*DJPublicKey, error) {
+	if s < 1 {
+		return nil, fmt.Errorf("s must be positive")
+	}
+	ns := new(big.Int).Exp(n, big.NewInt(int64(s)), nil)
+	return &DJPublicKey{s: s, n: new(big.Int).Set(n), ns: ns, ns1: new(big.Int).Mul(ns, n)}, nil
+}
+
+// DamgardJurik returns the Damgård–Jurik private key of the private key with the parameter s.
+func (pri *PrivateKey) DamgardJurik(s int) (*DJPrivateKey, error) {
+	pub, err := newDJPublicKey(pri.n, s)
+	if err != nil {
+		return nil, err
+	}
+	lamInv := new(big.Int).ModInverse(pri.lam, pub.ns)
+	if lamInv == nil {
+		return nil, fmt.Errorf("gcd(λ, n) != 1")
+	}
+	return &DJPrivateKey{DJPublicKey: *pub, lam: new(big.Int).Set(pri.lam), lamInv: lamInv}, nil
+}
+
+// S returns the parameter s.
+func (pub *DJPublicKey) S() int {
+	return pub.s
+}
+
+// N returns the modulus n.
+func (pub *DJPublicKey) N() *big.Int {
+	return new(big.Int).Set(pub.n)
+}
+
+// Ns returns n^s, the plaintexts are less than n^s.
+func (pub *DJPublicKey) Ns() *big.Int {
+	return new(big.Int).Set(pub.ns)
+}
+
+// PublicKey returns the public key of the private key.
+func (pri *DJPrivateKey) PublicKey() *DJPublicKey {
+	pub := pri.DJPublicKey
+	return &pub
+}
+
+// Encryption returns an encrypted data.
+func (pub *DJPublicKey) Encryption(m *big.Int) (*big.Int, error) {
+	return pub.EncryptionWithRn(m, pub.NewRn())
+}
+
+// NewRn returns r^(n^s) mod n^(s+1) for a random r in Z*_n,
+// which can be precomputed before the plaintext is known.
+func (pub *DJPublicKey) NewRn() *big.Int {
+	// select a random r < n
+	r := new(big.Int)
+	for {
+		r = Rnd(pub.n)
+		if r.Cmp(ZERO) != 0 && GCD(r, pub.n).Cmp(ONE) == 0 {
+			break
+		}
+	}
+	return new(big.Int).Exp(r, pub.ns, pub.ns1)
+}
+
+// EncryptionWithRn returns an encrypted data using the precomputed rn = r^(n^s) mod n^(s+1).
+// rn must be used only once.
+func (pub *DJPublicKey) EncryptionWithRn(m, rn *big.Int) (*big.Int, error) {
+	// plaintext m < n^s
+	if m.Cmp(ZERO) < 0 || m.Cmp(pub.ns) >= 0 {
+		return nil, fmt.Errorf("m is out of range")
+	}
+	// ciphertext c = (1 + n)^m * r^(n^s) mod n^(s+1)
+	c := new(big.Int).Mod(new(big.Int).Mul(pub.gm(m), rn), pub.ns1)
+	return c, nil
+}
+
+// gm returns (1 + n)^m mod n^(s+1) by the binomial theorem,
+// (1 + n)^m = Σ_{k=0}^{s} C(m, k) * n^k mod n^(s+1) .
+func (pub *DJPublicKey) gm(m *big.Int) *big.Int {
+	gm := big.NewInt(1)
+	// C(m, k) * k! = m * (m - 1) * ... * (m - k + 1)
+	c := big.NewInt(1)
+	fact := big.NewInt(1)
+	nk := big.NewInt(1)
+	for k := 1; k <= pub.s; k++ {
+		c.Mul(c, new(big.Int).Sub(m, big.NewInt(int64(k-1)))).Mod(c, pub.ns1)
+		fact.Mul(fact, big.NewInt(int64(k)))
+		nk.Mul(nk, pub.n)
+		// k! is invertible, since k ≤ s is less than the factors of n.
+		t := new(big.Int).ModInverse(fact, pub.ns1)
+		t.Mul(t, c).Mul(t, nk)
+		gm.Add(gm, t).Mod(gm, pub.ns1)
+	}
+	return gm
+}
+
+// Mul returns c1 * c2 mod n^(s+1)
+func (pub *DJPublicKey) Mul(c1, c2 *big.Int) *big.Int {
+	// c1 * c2 -> m1 + m2
+	return new(big.Int).Mod(new(big.Int).Mul(c1, c2), pub.ns1)
+}
+
+// Exp returns c ^ m mod n^(s+1)
+func (pub *DJPublicKey) Exp(c, m *big.Int) *big.Int {
+	// c ^ m2 -> m1 * m2
+	return new(big.Int).Exp(c, m, pub.ns1)
+}
+
+// Decryption returns the decrypted data.
+func (pri *DJPrivateKey) Decryption(c *big.Int) (*big.Int, error) {
+	// ciphertext c < n^(s+1)
+	if c.Cmp(ZERO) <= 0 || c.Cmp(pri.ns1) >= 0 || GCD(new(big.Int).Set(c), pri.n).Cmp(ONE) != 0 {
+		return nil, fmt.Errorf("c is out of range")
+	}
+	// c^λ = (1 + n)^(mλ mod n^s) mod n^(s+1)
+	i, err := pri.dlog(new(big.Int).Exp(c, pri.lam, pri.ns1))
+	if err != nil {
+		return nil, err
+	}
+	// m = (mλ mod n^s) * λ^-1 mod n^s
+	return i.Mod(i.Mul(i, pri.lamInv), pri.ns), nil
+}
+
+// dlog returns i such that a = (1 + n)^i mod n^(s+1) by the algorithm of Damgård and Jurik.
+//
+//	i := 0
+//	for j := 1 to s do
+//	  t1 := L(a mod n^(j+1))
+//	  t2 := i
+//	  for k := 2 to j do
+//	    i := i - 1
+//	    t2 := t2 * i mod n^j
+//	    t1 := t1 - (t2 * n^(k-1)) / k! mod n^j
+//	  i := t1
+func (pub *DJPublicKey) dlog(a *big.Int) (*big.Int, error) {
+	i := big.NewInt(0)
+	nj := big.NewInt(1)
+	for j := 1; j <= pub.s; j++ {
+		// n^j , n^(j+1)
+		nj.Mul(nj, pub.n)
+		nj1 := new(big.Int).Mul(nj, pub.n)
+		t1 := L(new(big.Int).Mod(a, nj1), pub.n)
+		if t1 == nil {
+			return nil, fmt.Errorf("c is not in Z*_n^(s+1)")
+		}
+		t1.Mod(t1, nj)
+		t2 := new(big.Int).Set(i)
+		fact := big.NewInt(1)
+		nk := big.NewInt(1)
+		for k := 2; k <= j; k++ {
+			i.Sub(i, ONE)
+			t2.Mod(t2.Mul(t2, i), nj)
+			fact.Mul(fact, big.NewInt(int64(k)))
+			nk.Mul(nk, pub.n)
+			// t1 := t1 - (t2 * n^(k-1)) / k! mod n^j
+			t := new(big.Int).ModInverse(fact, nj)
+			t.Mul(t, t2).Mul(t, nk)
+			t1.Sub(t1, t).Mod(t1, nj)
+		}
+		i.Set(t1)
+	}
+	return i, nil
+}
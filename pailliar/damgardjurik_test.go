@@ -0,0 +1,83 @@
+package pailliar_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/tnakagawa/goref/pailliar"
+)
+
+func TestDamgardJurik(t *testing.T) {
+	pub, pri, err := pailliar.KeyGeneration(512)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	for s := 1; s <= 4; s++ {
+		djpub, err := pub.DamgardJurik(s)
+		if err != nil {
+			t.Fatalf("error %v", err)
+		}
+		djpri, err := pri.DamgardJurik(s)
+		if err != nil {
+			t.Fatalf("error %v", err)
+		}
+		if djpub.Ns().BitLen() < 511*s {
+			t.Errorf("s = %d : n^s is too small", s)
+		}
+		ns := djpub.Ns()
+		ms := []*big.Int{big.NewInt(0), big.NewInt(1), big.NewInt(int64(s)), new(big.Int).Sub(ns, big.NewInt(1)), pailliar.Rnd(ns)}
+		for _, m := range ms {
+			c, err := djpub.Encryption(m)
+			if err != nil {
+				t.Fatalf("error %v", err)
+			}
+			x, err := djpri.Decryption(c)
+			if err != nil {
+				t.Fatalf("error %v", err)
+			}
+			if m.Cmp(x) != 0 {
+				t.Errorf("s = %d : m != x : %v != %v", s, m, x)
+			}
+		}
+		// homomorphic operations
+		m1, m2, k := pailliar.Rnd(ns), pailliar.Rnd(ns), pailliar.Rnd(ns)
+		c1, _ := djpub.Encryption(m1)
+		c2, _ := djpub.Encryption(m2)
+		x, err := djpri.Decryption(djpub.Mul(c1, c2))
+		if err != nil {
+			t.Fatalf("error %v", err)
+		}
+		if x.Cmp(new(big.Int).Mod(new(big.Int).Add(m1, m2), ns)) != 0 {
+			t.Errorf("s = %d : m1 + m2 != x", s)
+		}
+		x, err = djpri.Decryption(djpub.Exp(c1, k))
+		if err != nil {
+			t.Fatalf("error %v", err)
+		}
+		if x.Cmp(new(big.Int).Mod(new(big.Int).Mul(m1, k), ns)) != 0 {
+			t.Errorf("s = %d : m1 * k != x", s)
+		}
+		_, err = djpub.Encryption(ns)
+		if err == nil {
+			t.Errorf("s = %d : m = n^s : Encryption must fail", s)
+		}
+	}
+	// s = 1 is pailliar cipher with g = n + 1
+	djpub, _ := pub.DamgardJurik(1)
+	djpri, _ := pri.DamgardJurik(1)
+	m := pailliar.Rnd(pub.N())
+	c, _ := pub.Encryption(m)
+	x, err := djpri.Decryption(c)
+	if err != nil || x.Cmp(m) != 0 {
+		t.Errorf("s = 1 : m != x : %v != %v %v", m, x, err)
+	}
+	c, _ = djpub.Encryption(m)
+	x, err = pri.Decryption(c)
+	if err != nil || x.Cmp(m) != 0 {
+		t.Errorf("s = 1 : m != x : %v != %v %v", m, x, err)
+	}
+	_, err = pub.DamgardJurik(0)
+	if err == nil {
+		t.Errorf("s = 0 : DamgardJurik must fail")
+	}
+}
@@ -0,0 +1,176 @@
+package pailliar
+
+import (
+	"encoding/asn1"
+	"encoding/json"
+	"fmt"
+	"math/big"
+)
+
+// The ASN.1 structures of the keys.
+//
+// PailliarPublicKey ::= SEQUENCE {
+//     n INTEGER, -- the modulus
+//     g INTEGER  -- the base
+// }
+//
+// PailliarPrivateKey ::= SEQUENCE {
+//     version INTEGER, -- 0
+//     n       INTEGER, -- the modulus
+//     g       INTEGER, -- the base
+//     p       INTEGER, -- the prime p
+//     q       INTEGER  -- the prime q
+// }
+
+type publicKeyASN1 struct {
+	N *big.Int
+	G *big.Int
+}
+
+type privateKeyASN1 struct {
+	Version int
+	N       *big.Int
+	G       *big.Int
+	P       *big.Int
+	Q       *big.Int
+}
+
+// The JSON structures of the keys, the integers are encoded as hexadecimal strings.
+
+type publicKeyJSON struct {
+	N string `json:"n"`
+	G string `json:"g"`
+}
+
+type privateKeyJSON struct {
+	N string `json:"n"`
+	G string `json:"g"`
+	P string `json:"p"`
+	Q string `json:"q"`
+}
+
+// parseHex returns the integer of the hexadecimal string.
+func parseHex(name, s string) (*big.Int, error) {
+	x, ok := new(big.Int).SetString(s, 16)
+	if !ok || x.Sign() < 0 {
+		return nil, fmt.Errorf("illegal %s : %q", name, s)
+	}
+	return x, nil
+}
+
+// MarshalDER returns the ASN.1 DER encoding of the public key.
+func (pub *PublicKey) MarshalDER() ([]byte, error) {
+	return asn1.Marshal(publicKeyASN1{N: pub.n, G: pub.g})
+}
+
+// UnmarshalDER sets the public key from the ASN.1 DER encoding and validates it.
+func (pub *PublicKey) UnmarshalDER(der []byte) error {
+	k := &publicKeyASN1{}
+	rest, err := asn1.Unmarshal(der, k)
+	if err != nil {
+		return err
+	}
+	if len(rest) != 0 {
+		return fmt.Errorf("trailing data")
+	}
+	return pub.set(k.N, k.G)
+}
+
+// MarshalJSON returns the JSON encoding of the public key.
+func (pub *PublicKey) MarshalJSON() ([]byte, error) {
+	return json.Marshal(publicKeyJSON{N: pub.n.Text(16), G: pub.g.Text(16)})
+}
+
+// UnmarshalJSON sets the public key from the JSON encoding and validates it.
+func (pub *PublicKey) UnmarshalJSON(data []byte) error {
+	k := &publicKeyJSON{}
+	err := json.Unmarshal(data, k)
+	if err != nil {
+		return err
+	}
+	n, err := parseHex("n", k.N)
+	if err != nil {
+		return err
+	}
+	g, err := parseHex("g", k.G)
+	if err != nil {
+		return err
+	}
+	return pub.set(n, g)
+}
+
+// set sets the public key if it is valid.
+func (pub *PublicKey) set(n, g *big.Int) error {
+	k, err := NewPublicKey(n, g)
+	if err != nil {
+		return err
+	}
+	*pub = *k
+	return nil
+}
+
+// MarshalDER returns the ASN.1 DER encoding of the private key.
+func (pri *PrivateKey) MarshalDER() ([]byte, error) {
+	return asn1.Marshal(privateKeyASN1{Version: 0, N: pri.n, G: pri.g, P: pri.p, Q: pri.q})
+}
+
+// UnmarshalDER sets the private key from the ASN.1 DER encoding and validates it.
+func (pri *PrivateKey) UnmarshalDER(der []byte) error {
+	k := &privateKeyASN1{}
+	rest, err := asn1.Unmarshal(der, k)
+	if err != nil {
+		return err
+	}
+	if len(rest) != 0 {
+		return fmt.Errorf("trailing data")
+	}
+	if k.Version != 0 {
+		return fmt.Errorf("unsupported version %d", k.Version)
+	}
+	return pri.set(k.N, k.G, k.P, k.Q)
+}
+
+// MarshalJSON returns the JSON encoding of the private key.
+func (pri *PrivateKey) MarshalJSON() ([]byte, error) {
+	return json.Marshal(privateKeyJSON{N: pri.n.Text(16), G: pri.g.Text(16), P: pri.p.Text(16), Q: pri.q.Text(16)})
+}
+
+// UnmarshalJSON sets the private key from the JSON encoding and validates it.
+func (pri *PrivateKey) UnmarshalJSON(data []byte) error {
+	k := &privateKeyJSON{}
+	err := json.Unmarshal(data, k)
+	if err != nil {
+		return err
+	}
+	n, err := parseHex("n", k.N)
+	if err != nil {
+		return err
+	}
+	g, err := parseHex("g", k.G)
+	if err != nil {
+		return err
+	}
+	p, err := parseHex("p", k.P)
+	if err != nil {
+		return err
+	}
+	q, err := parseHex("q", k.Q)
+	if err != nil {
+		return err
+	}
+	return pri.set(n, g, p, q)
+}
+
+// set sets the private key if it is valid and n = p * q.
+func (pri *PrivateKey) set(n, g, p, q *big.Int) error {
+	k, err := NewPrivateKey(p, q, g)
+	if err != nil {
+		return err
+	}
+	// n = p * q
+	if k.n.Cmp(n) != 0 {
+		return fmt.Errorf("n != p * q")
+	}
+	*pri = *k
+	return nil
+}
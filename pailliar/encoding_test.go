@@ -0,0 +1,145 @@
+package pailliar_test
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/tnakagawa/goref/pailliar"
+)
+
+func TestEncoding(t *testing.T) {
+	pub, pri, err := pailliar.KeyGeneration(1024)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	if pub.N().Cmp(new(big.Int).Mul(pri.P(), pri.Q())) != 0 {
+		t.Fatalf("n != p * q")
+	}
+	// JSON
+	bs, err := json.Marshal(pub)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	t.Logf("public key JSON : %s", bs)
+	pub2 := &pailliar.PublicKey{}
+	err = json.Unmarshal(bs, pub2)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	bs, err = json.Marshal(pri)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	pri2 := &pailliar.PrivateKey{}
+	err = json.Unmarshal(bs, pri2)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	roundTrip(t, "JSON", pub, pri, pub2, pri2)
+	// DER
+	der, err := pub.MarshalDER()
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	t.Logf("public key DER : %x", der)
+	pub3 := &pailliar.PublicKey{}
+	err = pub3.UnmarshalDER(der)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	err = pub3.UnmarshalDER(append(der, 0x00))
+	if err == nil {
+		t.Errorf("UnmarshalDER must fail with trailing data")
+	}
+	der, err = pri.MarshalDER()
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	pri3 := &pailliar.PrivateKey{}
+	err = pri3.UnmarshalDER(der)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	roundTrip(t, "DER", pub, pri, pub3, pri3)
+}
+
+// roundTrip checks that the loaded keys are the same as the original keys.
+func roundTrip(t *testing.T, name string, pub *pailliar.PublicKey, pri *pailliar.PrivateKey, pub2 *pailliar.PublicKey, pri2 *pailliar.PrivateKey) {
+	if pub.N().Cmp(pub2.N()) != 0 || pub.G().Cmp(pub2.G()) != 0 {
+		t.Errorf("%s : the public key is different", name)
+		return
+	}
+	if pri.N().Cmp(pri2.N()) != 0 || pri.G().Cmp(pri2.G()) != 0 || pri.P().Cmp(pri2.P()) != 0 || pri.Q().Cmp(pri2.Q()) != 0 {
+		t.Errorf("%s : the private key is different", name)
+		return
+	}
+	m := pailliar.Rnd(pub.N())
+	c, err := pub2.Encryption(m)
+	if err != nil {
+		t.Errorf("%s : error %v", name, err)
+		return
+	}
+	x, err := pri2.Decryption(c)
+	if err != nil {
+		t.Errorf("%s : error %v", name, err)
+		return
+	}
+	if m.Cmp(x) != 0 {
+		t.Errorf("%s : m != x : %v != %v", name, m, x)
+		return
+	}
+	t.Logf("%s : round trip success", name)
+}
+
+func TestValidation(t *testing.T) {
+	p := big.NewInt(1000003)
+	q := big.NewInt(1000033)
+	pub, pri, err := pailliar.KeyFromPrimes(p, q)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	m := big.NewInt(123456)
+	c, err := pub.Encryption(m)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	x, err := pri.Decryption(c)
+	if err != nil || x.Cmp(m) != 0 {
+		t.Fatalf("m != x : %v != %v %v", m, x, err)
+	}
+	tests := []struct {
+		name string
+		p, q *big.Int
+	}{
+		{"p == q", p, p},
+		{"composite", p, big.NewInt(1000001)},
+		// gcd(3 * 7, 2 * 6) = 3
+		{"gcd(pq, (p-1)(q-1)) != 1", big.NewInt(3), big.NewInt(7)},
+	}
+	for _, test := range tests {
+		_, _, err = pailliar.KeyFromPrimes(test.p, test.q)
+		if err == nil {
+			t.Errorf("%s : KeyFromPrimes must fail", test.name)
+			continue
+		}
+		t.Logf("%s : %v", test.name, err)
+	}
+	// n != p * q
+	bs, _ := json.Marshal(map[string]string{"n": new(big.Int).Add(pub.N(), big.NewInt(2)).Text(16), "g": pub.G().Text(16), "p": p.Text(16), "q": q.Text(16)})
+	err = json.Unmarshal(bs, &pailliar.PrivateKey{})
+	if err == nil {
+		t.Errorf("n != p * q : UnmarshalJSON must fail")
+	}
+	// g is not in Z*_{n^2}
+	bs, _ = json.Marshal(map[string]string{"n": pub.N().Text(16), "g": p.Text(16)})
+	err = json.Unmarshal(bs, &pailliar.PublicKey{})
+	if err == nil {
+		t.Errorf("gcd(g, n) != 1 : UnmarshalJSON must fail")
+	}
+	// even n
+	_, err = pailliar.NewPublicKey(big.NewInt(1000), big.NewInt(1001))
+	if err == nil {
+		t.Errorf("even n : NewPublicKey must fail")
+	}
+}
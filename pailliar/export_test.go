@@ -0,0 +1,10 @@
+package pailliar
+
+// DecryptionLambda exports decryptionLambda for the tests and the benchmarks.
+var DecryptionLambda = (*PrivateKey).decryptionLambda
+
+// MillerRabin exports millerRabin for the tests.
+var MillerRabin = millerRabin
+
+// StrongLucas exports strongLucas for the tests.
+var StrongLucas = strongLucas
@@ -1,6 +1,7 @@
 package pailliar
 
 import (
+	"context"
 	"crypto/rand"
 	"fmt"
 	"math/big"
@@ -44,27 +45,92 @@ type PublicKey struct {
 	n2 *big.Int // n^2
 }
 
+// N returns the modulus n.
+func (pub *PublicKey) N() *big.Int {
+	return new(big.Int).Set(pub.n)
+}
+
+// G returns the base g.
+func (pub *PublicKey) G() *big.Int {
+	return new(big.Int).Set(pub.g)
+}
+
+// NewPublicKey returns the public key of the modulus n and the base g.
+func NewPublicKey(n, g *big.Int) (*PublicKey, error) {
+	pub := &PublicKey{n: new(big.Int).Set(n), g: new(big.Int).Set(g), n2: new(big.Int).Mul(n, n)}
+	err := pub.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return pub, nil
+}
+
+// Validate checks the public key.
+func (pub *PublicKey) Validate() error {
+	if pub.n == nil || pub.g == nil || pub.n2 == nil {
+		return fmt.Errorf("missing parameters")
+	}
+	// n is odd and greater than 1
+	if pub.n.Cmp(ONE) <= 0 || pub.n.Bit(0) == 0 {
+		return fmt.Errorf("illegal n")
+	}
+	// n^2 = n * n
+	if pub.n2.Cmp(new(big.Int).Mul(pub.n, pub.n)) != 0 {
+		return fmt.Errorf("n^2 != n * n")
+	}
+	// g in Z*_{n^2}
+	if pub.g.Cmp(ZERO) <= 0 || pub.g.Cmp(pub.n2) >= 0 || GCD(pub.g, pub.n).Cmp(ONE) != 0 {
+		return fmt.Errorf("g is not in Z*_{n^2}")
+	}
+	return nil
+}
+
 // Encryption returns an encrypted data.
 func (pub *PublicKey) Encryption(m *big.Int) (*big.Int, error) {
 	// plaintext m < n
 	if m.Cmp(ZERO) < 0 || m.Cmp(pub.n) >= 0 {
 		return nil, fmt.Errorf("m is out of range")
 	}
+	return pub.EncryptionWithRn(m, pub.NewRn())
+}
+
+// NewRn returns r^n mod n^2 for a random r in Z*_n,
+// which can be precomputed before the plaintext is known.
+func (pub *PublicKey) NewRn() *big.Int {
 	// select a random r < n
 	r := new(big.Int)
 	for {
 		r = Rnd(pub.n)
-		if GCD(r, pub.n).Cmp(ONE) == 0 {
+		if r.Cmp(ZERO) != 0 && GCD(r, pub.n).Cmp(ONE) == 0 {
 			break
 		}
 	}
+	return new(big.Int).Exp(r, pub.n, pub.n2)
+}
+
+// EncryptionWithRn returns an encrypted data using the precomputed rn = r^n mod n^2.
+// rn must be used only once.
+func (pub *PublicKey) EncryptionWithRn(m, rn *big.Int) (*big.Int, error) {
+	// plaintext m < n
+	if m.Cmp(ZERO) < 0 || m.Cmp(pub.n) >= 0 {
+		return nil, fmt.Errorf("m is out of range")
+	}
 	// ciphertext c = g^m * r^n mod n^2
-	c := new(big.Int).Mod(new(big.Int).Mul(
-		new(big.Int).Exp(pub.g, m, pub.n2),
-		new(big.Int).Exp(r, pub.n, pub.n2)), pub.n2)
+	c := new(big.Int).Mod(new(big.Int).Mul(pub.gm(m), rn), pub.n2)
 	return c, nil
 }
 
+// gm returns g^m mod n^2.
+func (pub *PublicKey) gm(m *big.Int) *big.Int {
+	// if g = n + 1 then g^m = (1 + n)^m = 1 + m * n mod n^2
+	if pub.g.Cmp(new(big.Int).Add(pub.n, ONE)) == 0 {
+		gm := new(big.Int).Mul(m, pub.n)
+		gm.Add(gm, ONE)
+		return gm.Mod(gm, pub.n2)
+	}
+	return new(big.Int).Exp(pub.g, m, pub.n2)
+}
+
 // Mul returns c1 * c2 mod n2
 func (pub *PublicKey) Mul(c1, c2 *big.Int) *big.Int {
 	// c1 * c2 -> m1 + m2
@@ -83,18 +149,153 @@ type PrivateKey struct {
 	mu  *big.Int // μ = 1 / L(g^λ mod n^2)
 	n   *big.Int // n = p * q
 	n2  *big.Int // n^2
+	p   *big.Int // p
+	q   *big.Int // q
+	g   *big.Int // g
+	// the precomputed values for the CRT decryption
+	p2   *big.Int // p^2
+	q2   *big.Int // q^2
+	hp   *big.Int // hp = 1 / L_p(g^(p-1) mod p^2) mod p
+	hq   *big.Int // hq = 1 / L_q(g^(q-1) mod q^2) mod q
+	qInv *big.Int // 1 / q mod p
+}
+
+// PublicKey returns the public key of the private key.
+func (pri *PrivateKey) PublicKey() *PublicKey {
+	return &PublicKey{n: new(big.Int).Set(pri.n), g: new(big.Int).Set(pri.g), n2: new(big.Int).Set(pri.n2)}
+}
+
+// N returns the modulus n.
+func (pri *PrivateKey) N() *big.Int {
+	return new(big.Int).Set(pri.n)
+}
+
+// G returns the base g.
+func (pri *PrivateKey) G() *big.Int {
+	return new(big.Int).Set(pri.g)
+}
+
+// P returns the prime p.
+func (pri *PrivateKey) P() *big.Int {
+	return new(big.Int).Set(pri.p)
+}
+
+// Q returns the prime q.
+func (pri *PrivateKey) Q() *big.Int {
+	return new(big.Int).Set(pri.q)
+}
+
+// NewPrivateKey returns the private key of the primes p and q and the base g.
+func NewPrivateKey(p, q, g *big.Int) (*PrivateKey, error) {
+	pri := newPrivateKey(p, q, g)
+	err := pri.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return pri, nil
+}
+
+// newPrivateKey returns the private key without the validation.
+func newPrivateKey(p, q, g *big.Int) *PrivateKey {
+	// n = p * q
+	n := new(big.Int).Mul(p, q)
+	// n^2 = n * n
+	n2 := new(big.Int).Mul(n, n)
+	// λ = lcm(p-1,q-1)
+	lam := LCM(new(big.Int).Sub(p, ONE), new(big.Int).Sub(q, ONE))
+	pri := &PrivateKey{lam: lam, n: n, n2: n2, p: new(big.Int).Set(p), q: new(big.Int).Set(q), g: new(big.Int).Set(g)}
+	// μ = 1 / L(g^λ mod n^2)
+	mu := L(new(big.Int).Exp(g, lam, n2), n)
+	if mu != nil {
+		pri.mu = new(big.Int).ModInverse(mu, n)
+	}
+	// hp = 1 / L_p(g^(p-1) mod p^2) mod p
+	pri.p2 = new(big.Int).Mul(p, p)
+	pri.hp = hInverse(g, p, pri.p2)
+	// hq = 1 / L_q(g^(q-1) mod q^2) mod q
+	pri.q2 = new(big.Int).Mul(q, q)
+	pri.hq = hInverse(g, q, pri.q2)
+	pri.qInv = new(big.Int).ModInverse(q, p)
+	return pri
+}
+
+// hInverse returns 1 / L_p(g^(p-1) mod p^2) mod p, or nil if it does not exist.
+func hInverse(g, p, p2 *big.Int) *big.Int {
+	h := L(new(big.Int).Exp(g, new(big.Int).Sub(p, ONE), p2), p)
+	if h == nil {
+		return nil
+	}
+	return new(big.Int).ModInverse(h.Mod(h, p), p)
+}
+
+// Validate checks the private key.
+func (pri *PrivateKey) Validate() error {
+	if pri.p == nil || pri.q == nil || pri.g == nil || pri.n == nil {
+		return fmt.Errorf("missing parameters")
+	}
+	// p and q are distinct primes
+	if !IsProbablyPrime(pri.p) || !IsProbablyPrime(pri.q) {
+		return fmt.Errorf("p or q is not prime")
+	}
+	if pri.p.Cmp(pri.q) == 0 {
+		return fmt.Errorf("p == q")
+	}
+	// n = p * q
+	if pri.n.Cmp(new(big.Int).Mul(pri.p, pri.q)) != 0 {
+		return fmt.Errorf("n != p * q")
+	}
+	// gcd(pq, (p-1)(q-1)) = 1
+	phi := new(big.Int).Mul(new(big.Int).Sub(pri.p, ONE), new(big.Int).Sub(pri.q, ONE))
+	if GCD(pri.n, phi).Cmp(ONE) != 0 {
+		return fmt.Errorf("gcd(pq, (p-1)(q-1)) != 1")
+	}
+	err := pri.PublicKey().Validate()
+	if err != nil {
+		return err
+	}
+	// gcd(L(g^λ mod n^2 ), n) = 1
+	if pri.mu == nil || pri.hp == nil || pri.hq == nil || pri.qInv == nil {
+		return fmt.Errorf("gcd(L(g^λ mod n^2), n) != 1")
+	}
+	return nil
 }
 
 // Decryption returns the decrypted data.
+// It decrypts modulo p^2 and q^2 separately and combines them by the Chinese remainder theorem.
 func (pri *PrivateKey) Decryption(c *big.Int) (*big.Int, error) {
+	// ciphertext c < n 2
+	if c.Cmp(ZERO) <= 0 || c.Cmp(pri.n2) >= 0 {
+		return nil, fmt.Errorf("c is out of range")
+	}
+	// mp = L_p(c^(p-1) mod p^2) * hp mod p
+	mp := L(new(big.Int).Exp(c, new(big.Int).Sub(pri.p, ONE), pri.p2), pri.p)
+	// mq = L_q(c^(q-1) mod q^2) * hq mod q
+	mq := L(new(big.Int).Exp(c, new(big.Int).Sub(pri.q, ONE), pri.q2), pri.q)
+	if mp == nil || mq == nil {
+		return nil, fmt.Errorf("c is not in Z*_{n^2}")
+	}
+	mp.Mod(mp.Mul(mp, pri.hp), pri.p)
+	mq.Mod(mq.Mul(mq, pri.hq), pri.q)
+	// m = CRT(mp, mq) mod pq = mq + ((mp - mq) * (1 / q mod p) mod p) * q
+	m := new(big.Int).Sub(mp, mq)
+	m.Mod(m.Mul(m, pri.qInv), pri.p)
+	m.Add(m.Mul(m, pri.q), mq)
+	return m, nil
+}
+
+// decryptionLambda returns the decrypted data without the Chinese remainder theorem.
+func (pri *PrivateKey) decryptionLambda(c *big.Int) (*big.Int, error) {
 	// ciphertext c < n 2
 	if c.Cmp(ZERO) <= 0 || c.Cmp(pri.n2) >= 0 {
 		return nil, fmt.Errorf("c is out of range")
 	}
 	// plaintext m = L(c^λ mod n^2) / L(g^λ mod n^2) mod n
 	//             = L(c^λ mod n^2) * μ mod n
-	m := new(big.Int).Mod(new(big.Int).Mul(
-		L(new(big.Int).Exp(c, pri.lam, pri.n2), pri.n), pri.mu), pri.n)
+	l := L(new(big.Int).Exp(c, pri.lam, pri.n2), pri.n)
+	if l == nil {
+		return nil, fmt.Errorf("c is not in Z*_{n^2}")
+	}
+	m := new(big.Int).Mod(new(big.Int).Mul(l, pri.mu), pri.n)
 	return m, nil
 }
 
@@ -108,102 +309,62 @@ func L(x, n *big.Int) *big.Int {
 
 // KeyGeneration returns a public and a private keys of pailliar cipher.
 func KeyGeneration(bits int) (*PublicKey, *PrivateKey, error) {
+	return KeyGenerationContext(context.Background(), bits, false)
+}
+
+// KeyGenerationContext returns a public and a private keys of pailliar cipher,
+// or the error of ctx if it is done before the primes are found.
+// If safe is true, p and q are safe primes.
+func KeyGenerationContext(ctx context.Context, bits int, safe bool) (*PublicKey, *PrivateKey, error) {
 	// p and q are large primes
-	p := probablyPrime(bits / 2)
-	q := probablyPrime(bits / 2)
+	p, err := GeneratePrime(ctx, bits/2, safe)
+	if err != nil {
+		return nil, nil, err
+	}
+	q, err := GeneratePrime(ctx, bits-bits/2, safe)
+	if err != nil {
+		return nil, nil, err
+	}
 	if p.Cmp(q) == 0 {
-		return KeyGeneration(bits)
+		return KeyGenerationContext(ctx, bits, safe)
 	}
-	// n = p * q
+	pri, err := keyFromPrimes(p, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pri.PublicKey(), pri, nil
+}
+
+// KeyFromPrimes returns a public and a private keys of pailliar cipher for the primes p and q.
+func KeyFromPrimes(p, q *big.Int) (*PublicKey, *PrivateKey, error) {
+	// p and q are distinct primes
+	if !IsProbablyPrime(p) || !IsProbablyPrime(q) {
+		return nil, nil, fmt.Errorf("p or q is not prime")
+	}
+	if p.Cmp(q) == 0 {
+		return nil, nil, fmt.Errorf("p == q")
+	}
+	pri, err := keyFromPrimes(p, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pri.PublicKey(), pri, nil
+}
+
+// keyFromPrimes returns the private key for the primes p and q with the base g = n + 1.
+func keyFromPrimes(p, q *big.Int) (*PrivateKey, error) {
 	n := new(big.Int).Mul(p, q)
-	// n^2 = n * n
-	n2 := new(big.Int).Mul(n, n)
-	// λ = lcm(p-1,q-1)
-	lam := LCM(new(big.Int).Sub(p, ONE), new(big.Int).Sub(q, ONE))
-	// randomly select a base g
-	g := new(big.Int)
-	// μ = 1 / L(g^λ mod n^2)
-	mu := new(big.Int)
-	for {
-		g = Rnd(n2)
-		if GCD(g, n2).Cmp(ONE) != 0 {
-			continue
-		}
-		mu = L(new(big.Int).Exp(g, lam, n2), n)
-		// gcd(L(g^λ mod n^2 ), n) = 1
-		if GCD(mu, n).Cmp(ONE) != 0 {
-			continue
-		}
-		mu = new(big.Int).ModInverse(mu, n)
-		break
-	}
-	// public key
-	pub := &PublicKey{n: n, g: g, n2: n2}
-	// private key
-	pri := &PrivateKey{lam: lam, mu: mu, n: n, n2: n2}
-	return pub, pri, nil
-}
-
-func probablyPrime(bits int) *big.Int {
-	max := new(big.Int).Exp(big.NewInt(2), big.NewInt(int64(bits)), nil)
-	p := big.NewInt(0)
-	cnt := 0
-	for !IsProbablyPrime(p) {
-		p = Rnd(max)
-		for p.BitLen() != bits {
-			p = Rnd(max)
-		}
-		cnt++
-	}
-	return p
-}
-
-// IsProbablyPrime :
-func IsProbablyPrime(n *big.Int) bool {
-	ONE := big.NewInt(1)
-	TWO := big.NewInt(2)
-	if n.Cmp(ONE) <= 0 {
-		return false
-	}
-	// if n == 2 then prime
-	if n.Cmp(TWO) == 0 {
-		return true
-	}
-	// even check
-	if n.Bit(0) == 0 {
-		return false
-	}
-	// n-1 = 2^s * d
-	d := new(big.Int).Sub(n, ONE)
-	s := big.NewInt(0)
-	for d.Bit(0) == 0 {
-		d.Rsh(d, 1)
-		s.Add(s, ONE)
-	}
-	k := 512
-	nm1 := new(big.Int).Sub(n, ONE)
-	for i := 0; i < k; i++ {
-		// a in [1,n-1]
-		a := Rnd(nm1)
-		a.Add(a, ONE)
-		// a^{d} mod n == 1
-		t := new(big.Int).Exp(a, d, n)
-		if t.Cmp(ONE) == 0 {
-			continue
-		}
-		flg := false
-		// r in [0,s-1] , a^{2^rd} mod n == -1
-		// a^{2^0 * d} , a^{2^1 * d} , a^{2^2 * d} , .... , a^{2^(s-1) * d}
-		for r := big.NewInt(0); r.Cmp(s) < 0; r.Add(r, ONE) {
-			if t.Cmp(nm1) == 0 {
-				flg = true
-				break
-			}
-			t.Exp(t, TWO, n)
-		}
-		if !flg {
-			return false
-		}
+	// gcd(pq, (p-1)(q-1)) = 1
+	phi := new(big.Int).Mul(new(big.Int).Sub(p, ONE), new(big.Int).Sub(q, ONE))
+	if GCD(n, phi).Cmp(ONE) != 0 {
+		return nil, fmt.Errorf("gcd(pq, (p-1)(q-1)) != 1")
+	}
+	// g = n + 1 is always a valid base, since L(g^λ mod n^2) = λ mod n and gcd(λ, n) = 1.
+	// g^m = 1 + m * n mod n^2 makes the encryption faster.
+	g := new(big.Int).Add(n, ONE)
+	pri := newPrivateKey(p, q, g)
+	if pri.mu == nil {
+		return nil, fmt.Errorf("gcd(L(g^λ mod n^2), n) != 1")
 	}
-	return true
+	return pri, nil
 }
@@ -126,3 +126,124 @@ func TestProbablyPrime2(t *testing.T) {
 		t.Logf("%8d %5v %5v", k, v, r)
 	}
 }
+
+func TestFastPath(t *testing.T) {
+	pub, pri, err := pailliar.KeyGeneration(1024)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	// g = n + 1
+	if pub.G().Cmp(new(big.Int).Add(pub.N(), big.NewInt(1))) != 0 {
+		t.Fatalf("g != n + 1")
+	}
+	// the key with a random base g
+	n2 := new(big.Int).Mul(pub.N(), pub.N())
+	var pri2 *pailliar.PrivateKey
+	for pri2 == nil {
+		pri2, _ = pailliar.NewPrivateKey(pri.P(), pri.Q(), pailliar.Rnd(n2))
+	}
+	pub2 := pri2.PublicKey()
+	for i, k := range []*pailliar.PrivateKey{pri, pri2} {
+		pk := k.PublicKey()
+		for j := 0; j < 4; j++ {
+			m := pailliar.Rnd(pk.N())
+			c, err := pk.Encryption(m)
+			if err != nil {
+				t.Fatalf("error %v", err)
+			}
+			if j%2 == 1 {
+				// the precomputed r^n
+				c, err = pk.EncryptionWithRn(m, pk.NewRn())
+				if err != nil {
+					t.Fatalf("error %v", err)
+				}
+			}
+			x, err := k.Decryption(c)
+			if err != nil {
+				t.Fatalf("error %v", err)
+			}
+			y, err := pailliar.DecryptionLambda(k, c)
+			if err != nil {
+				t.Fatalf("error %v", err)
+			}
+			if m.Cmp(x) != 0 || m.Cmp(y) != 0 {
+				t.Errorf("%d %d m != x, y : %v != %v, %v", i, j, m, x, y)
+				continue
+			}
+		}
+	}
+	t.Logf("g = n + 1 : %v , random g : %v", pub.G(), pub2.G())
+}
+
+// the keys for the benchmarks, g = n + 1 and random g
+var (
+	benchPub, benchPub2 *pailliar.PublicKey
+	benchPri, benchPri2 *pailliar.PrivateKey
+)
+
+func benchKeys(b *testing.B) {
+	if benchPri != nil {
+		return
+	}
+	var err error
+	benchPub, benchPri, err = pailliar.KeyGeneration(2048)
+	if err != nil {
+		b.Fatalf("error %v", err)
+	}
+	n2 := new(big.Int).Mul(benchPub.N(), benchPub.N())
+	for benchPri2 == nil {
+		benchPri2, _ = pailliar.NewPrivateKey(benchPri.P(), benchPri.Q(), pailliar.Rnd(n2))
+	}
+	benchPub2 = benchPri2.PublicKey()
+	b.ResetTimer()
+}
+
+// BenchmarkEncryption : g = n + 1
+func BenchmarkEncryption(b *testing.B) {
+	benchKeys(b)
+	m := pailliar.Rnd(benchPub.N())
+	for i := 0; i < b.N; i++ {
+		benchPub.Encryption(m)
+	}
+}
+
+// BenchmarkEncryptionRandomG : random g
+func BenchmarkEncryptionRandomG(b *testing.B) {
+	benchKeys(b)
+	m := pailliar.Rnd(benchPub2.N())
+	for i := 0; i < b.N; i++ {
+		benchPub2.Encryption(m)
+	}
+}
+
+// BenchmarkEncryptionWithRn : g = n + 1 and the precomputed r^n
+func BenchmarkEncryptionWithRn(b *testing.B) {
+	benchKeys(b)
+	m := pailliar.Rnd(benchPub.N())
+	rns := []*big.Int{}
+	for i := 0; i < b.N; i++ {
+		rns = append(rns, benchPub.NewRn())
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		benchPub.EncryptionWithRn(m, rns[i])
+	}
+}
+
+// BenchmarkDecryption : CRT
+func BenchmarkDecryption(b *testing.B) {
+	benchKeys(b)
+	c, _ := benchPub.Encryption(pailliar.Rnd(benchPub.N()))
+	for i := 0; i < b.N; i++ {
+		benchPri.Decryption(c)
+	}
+}
+
+// BenchmarkDecryptionLambda : L(c^λ mod n^2) * μ mod n
+func BenchmarkDecryptionLambda(b *testing.B) {
+	benchKeys(b)
+	c, _ := benchPub.Encryption(pailliar.Rnd(benchPub.N()))
+	for i := 0; i < b.N; i++ {
+		pailliar.DecryptionLambda(benchPri, c)
+	}
+}
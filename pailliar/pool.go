@@ -0,0 +1,185 @@
+package pailliar
+
+import (
+	"fmt"
+	"math/big"
+	"runtime"
+	"sync"
+)
+
+// Pool is the pool of the precomputed r^n mod n^2, which dominates the cost of the encryption.
+// The workers fill the pool in the background until Stop or Drain is called.
+// If the pool is empty, r^n mod n^2 is computed when it is needed,
+// so that the encryption by the pool is never slower than without the pool.
+type Pool struct {
+	pub  *PublicKey
+	rns  chan *big.Int
+	stop chan struct{}
+	once sync.Once
+	wg   sync.WaitGroup
+	mu   sync.Mutex
+	cond *sync.Cond // signaled when a value is added or the workers are stopped
+}
+
+// NewPool returns the pool of the size filled by the workers,
+// the number of the workers is the number of the CPUs if workers ≤ 0.
+func (pub *PublicKey) NewPool(size, workers int) (*Pool, error) {
+	if size <= 0 {
+		return nil, fmt.Errorf("size must be positive")
+	}
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	p := &Pool{pub: pub, rns: make(chan *big.Int, size), stop: make(chan struct{})}
+	p.cond = sync.NewCond(&p.mu)
+	p.wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer p.wg.Done()
+			for {
+				// stop before the next computation
+				select {
+				case <-p.stop:
+					return
+				default:
+				}
+				rn := pub.NewRn()
+				select {
+				case p.rns <- rn:
+					p.mu.Lock()
+					p.cond.Broadcast()
+					p.mu.Unlock()
+				case <-p.stop:
+					return
+				}
+			}
+		}()
+	}
+	return p, nil
+}
+
+// PublicKey returns the public key of the pool.
+func (p *Pool) PublicKey() *PublicKey {
+	return p.pub
+}
+
+// Len returns the number of the precomputed values in the pool.
+func (p *Pool) Len() int {
+	return len(p.rns)
+}
+
+// Wait waits until the pool is full or the workers are stopped, and returns Len.
+func (p *Pool) Wait() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for len(p.rns) < cap(p.rns) && !p.stopped() {
+		p.cond.Wait()
+	}
+	return len(p.rns)
+}
+
+// stopped returns whether Stop or Drain is called.
+func (p *Pool) stopped() bool {
+	select {
+	case <-p.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// Rn returns r^n mod n^2 from the pool, or computes it if the pool is empty.
+// Each value is returned only once.
+func (p *Pool) Rn() *big.Int {
+	select {
+	case rn := <-p.rns:
+		return rn
+	default:
+		return p.pub.NewRn()
+	}
+}
+
+// Stop stops the workers and waits for them,
+// the remaining precomputed values are still used until the pool is empty.
+func (p *Pool) Stop() {
+	p.once.Do(func() {
+		p.mu.Lock()
+		close(p.stop)
+		p.cond.Broadcast()
+		p.mu.Unlock()
+	})
+	p.wg.Wait()
+}
+
+// Drain stops the workers and discards the remaining precomputed values,
+// it returns the number of the discarded values.
+func (p *Pool) Drain() int {
+	p.Stop()
+	n := 0
+	for {
+		select {
+		case <-p.rns:
+			n++
+		default:
+			return n
+		}
+	}
+}
+
+// Encryption returns an encrypted data using the precomputed value.
+func (p *Pool) Encryption(m *big.Int) (*big.Int, error) {
+	return p.pub.EncryptionWithRn(m, p.Rn())
+}
+
+// Encrypt returns the ciphertext of the plaintext m using the precomputed value, where m is reduced modulo n.
+func (p *Pool) Encrypt(m *big.Int) (*Ciphertext, error) {
+	c, err := p.Encryption(new(big.Int).Mod(m, p.pub.n))
+	if err != nil {
+		return nil, err
+	}
+	return &Ciphertext{pub: p.pub, c: c}, nil
+}
+
+// BatchEncrypt returns the encrypted data of the plaintexts using the precomputed values,
+// the missing values are computed in parallel across the CPUs.
+func (p *Pool) BatchEncrypt(ms []*big.Int) ([]*big.Int, error) {
+	return p.pub.batchEncrypt(ms, p.Rn)
+}
+
+// BatchEncrypt returns the encrypted data of the plaintexts in parallel across the CPUs.
+func (pub *PublicKey) BatchEncrypt(ms []*big.Int) ([]*big.Int, error) {
+	return pub.batchEncrypt(ms, pub.NewRn)
+}
+
+// batchEncrypt returns the encrypted data of the plaintexts with r^n mod n^2 by rn.
+func (pub *PublicKey) batchEncrypt(ms []*big.Int, rn func() *big.Int) ([]*big.Int, error) {
+	// plaintext m < n
+	for i, m := range ms {
+		if m == nil || m.Cmp(ZERO) < 0 || m.Cmp(pub.n) >= 0 {
+			return nil, fmt.Errorf("%d : m is out of range", i)
+		}
+	}
+	cs := make([]*big.Int, len(ms))
+	workers := runtime.NumCPU()
+	if workers > len(ms) {
+		workers = len(ms)
+	}
+	next := make(chan int)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				// ciphertext c = g^m * r^n mod n^2
+				cs[i] = new(big.Int).Mod(new(big.Int).Mul(pub.gm(ms[i]), rn()), pub.n2)
+			}
+		}()
+	}
+	for i := range ms {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	return cs, nil
+}
@@ -0,0 +1,142 @@
+package pailliar_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/tnakagawa/goref/pailliar"
+)
+
+func TestPool(t *testing.T) {
+	pub, pri, err := pailliar.KeyGeneration(1024)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	const size = 16
+	pool, err := pub.NewPool(size, 2)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	// wait for the pool to be filled
+	if n := pool.Wait(); n != size {
+		t.Fatalf("the pool is not filled : %d", n)
+	}
+	if pool.Len() != size {
+		t.Fatalf("Len : %d != %d", pool.Len(), size)
+	}
+	check := func(name string, ms, cs []*big.Int) {
+		for i := range ms {
+			x, err := pri.Decryption(cs[i])
+			if err != nil {
+				t.Fatalf("%s : error %v", name, err)
+			}
+			if ms[i].Cmp(x) != 0 {
+				t.Errorf("%s %d : m != x : %v != %v", name, i, ms[i], x)
+			}
+		}
+	}
+	ms := []*big.Int{}
+	for i := 0; i < 4*size; i++ {
+		ms = append(ms, pailliar.Rnd(pub.N()))
+	}
+	c, err := pool.Encryption(ms[0])
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	check("Encryption", ms[:1], []*big.Int{c})
+	// the batch is larger than the pool
+	cs, err := pool.BatchEncrypt(ms)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	check("Pool.BatchEncrypt", ms, cs)
+	// the randomness is not reused
+	seen := map[string]bool{}
+	for _, c := range cs {
+		if seen[c.String()] {
+			t.Fatalf("the ciphertext is duplicated")
+		}
+		seen[c.String()] = true
+	}
+	// Stop keeps the remaining values
+	pool.Wait()
+	pool.Stop()
+	pool.Stop()
+	l := pool.Len()
+	if l != size {
+		t.Fatalf("Len after Stop : %d != %d", l, size)
+	}
+	// Wait returns at once after Stop
+	if n := pool.Wait(); n != l {
+		t.Errorf("Wait after Stop : %d != %d", n, l)
+	}
+	ct, err := pool.Encrypt(big.NewInt(-5))
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	if pool.Len() != l-1 {
+		t.Errorf("Len : %d != %d", pool.Len(), l-1)
+	}
+	x, err := pri.Decrypt(ct)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	if x.Cmp(new(big.Int).Sub(pub.N(), big.NewInt(5))) != 0 {
+		t.Errorf("Encrypt : x != n - 5")
+	}
+	// Drain discards the remaining values, and the encryption still works
+	if n := pool.Drain(); n != l-1 {
+		t.Errorf("Drain : %d != %d", n, l-1)
+	}
+	if pool.Len() != 0 {
+		t.Errorf("the pool is not empty after Drain : %d", pool.Len())
+	}
+	cs, err = pool.BatchEncrypt(ms[:3])
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	check("drained", ms[:3], cs)
+	// PublicKey.BatchEncrypt
+	cs, err = pub.BatchEncrypt(ms)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	check("PublicKey.BatchEncrypt", ms, cs)
+	_, err = pub.BatchEncrypt([]*big.Int{big.NewInt(1), pub.N()})
+	if err == nil {
+		t.Errorf("m = n : BatchEncrypt must fail")
+	}
+	_, err = pub.NewPool(0, 1)
+	if err == nil {
+		t.Errorf("size = 0 : NewPool must fail")
+	}
+}
+
+// BenchmarkBatchEncrypt : the encryption in parallel
+func BenchmarkBatchEncrypt(b *testing.B) {
+	benchKeys(b)
+	ms := []*big.Int{}
+	for i := 0; i < 64; i++ {
+		ms = append(ms, pailliar.Rnd(benchPub.N()))
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		benchPub.BatchEncrypt(ms)
+	}
+}
+
+// BenchmarkPoolEncryption : the encryption with the filled pool
+func BenchmarkPoolEncryption(b *testing.B) {
+	benchKeys(b)
+	pool, err := benchPub.NewPool(b.N, 0)
+	if err != nil {
+		b.Fatalf("error %v", err)
+	}
+	defer pool.Drain()
+	pool.Wait()
+	m := pailliar.Rnd(benchPub.N())
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		pool.Encryption(m)
+	}
+}
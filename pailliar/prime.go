@@ -0,0 +1,232 @@
+package pailliar
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"runtime"
+)
+
+// smallPrimes are the primes less than 2000 for the trial division.
+var smallPrimes = func() []*big.Int {
+	const limit = 2000
+	composite := make([]bool, limit)
+	primes := []*big.Int{}
+	for i := 2; i < limit; i++ {
+		if composite[i] {
+			continue
+		}
+		primes = append(primes, big.NewInt(int64(i)))
+		for j := i * i; j < limit; j += i {
+			composite[j] = true
+		}
+	}
+	return primes
+}()
+
+// hasSmallFactor returns whether n is divisible by a small prime other than n itself.
+func hasSmallFactor(n *big.Int) bool {
+	r := new(big.Int)
+	for _, sp := range smallPrimes {
+		if n.Cmp(sp) == 0 {
+			return false
+		}
+		if r.Mod(n, sp).Sign() == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// IsProbablyPrime returns whether n is probably prime by the Baillie–PSW test,
+// which is the trial division, the Miller–Rabin test to base 2 and the strong Lucas test.
+// No composite number passing the Baillie–PSW test is known.
+func IsProbablyPrime(n *big.Int) bool {
+	TWO := big.NewInt(2)
+	if n.Cmp(TWO) < 0 {
+		return false
+	}
+	// trial division by small primes
+	if hasSmallFactor(n) {
+		return false
+	}
+	// n has no factor less than sqrt(n)
+	largest := smallPrimes[len(smallPrimes)-1]
+	if n.Cmp(new(big.Int).Mul(largest, largest)) < 0 {
+		return true
+	}
+	return millerRabin(n, TWO) && strongLucas(n)
+}
+
+// millerRabin returns whether n is a strong probable prime to base a.
+func millerRabin(n, a *big.Int) bool {
+	// n-1 = 2^s * d
+	nm1 := new(big.Int).Sub(n, ONE)
+	d := new(big.Int).Set(nm1)
+	s := 0
+	for d.Bit(0) == 0 {
+		d.Rsh(d, 1)
+		s++
+	}
+	// a^{d} mod n == 1
+	t := new(big.Int).Exp(a, d, n)
+	if t.Cmp(ONE) == 0 {
+		return true
+	}
+	// r in [0,s-1] , a^{2^rd} mod n == -1
+	for r := 0; r < s; r++ {
+		if t.Cmp(nm1) == 0 {
+			return true
+		}
+		t.Exp(t, big.NewInt(2), n)
+	}
+	return false
+}
+
+// strongLucas returns whether the odd n is a strong Lucas probable prime
+// with the parameters chosen by Selfridge's method A.
+func strongLucas(n *big.Int) bool {
+	// The perfect square has no D with (D/n) = -1.
+	sqrt := new(big.Int).Sqrt(n)
+	if new(big.Int).Mul(sqrt, sqrt).Cmp(n) == 0 {
+		return false
+	}
+	// D is the first of 5, -7, 9, -11, 13, ... with the Jacobi symbol (D/n) = -1.
+	D := big.NewInt(5)
+	for {
+		j := big.Jacobi(new(big.Int).Mod(D, n), n)
+		if j == -1 {
+			break
+		}
+		if j == 0 && new(big.Int).Abs(D).Cmp(n) != 0 {
+			return false
+		}
+		if D.Sign() > 0 {
+			D.Add(D, big.NewInt(2)).Neg(D)
+		} else {
+			D.Sub(D, big.NewInt(2)).Neg(D)
+		}
+	}
+	// P = 1, Q = (1 - D) / 4
+	P := big.NewInt(1)
+	Q := new(big.Int).Sub(ONE, D)
+	Q.Div(Q, big.NewInt(4))
+	// n+1 = 2^s * d
+	d := new(big.Int).Add(n, ONE)
+	s := 0
+	for d.Bit(0) == 0 {
+		d.Rsh(d, 1)
+		s++
+	}
+	// half returns x / 2 mod n.
+	half := func(x *big.Int) *big.Int {
+		if x.Bit(0) == 1 {
+			x.Add(x, n)
+		}
+		return x.Rsh(x, 1)
+	}
+	Dn := new(big.Int).Mod(D, n)
+	Qn := new(big.Int).Mod(Q, n)
+	// U_1 = 1, V_1 = P, Q^1
+	U := big.NewInt(1)
+	V := new(big.Int).Set(P)
+	Qk := new(big.Int).Set(Qn)
+	for i := d.BitLen() - 2; i >= 0; i-- {
+		// U_2k = U_k * V_k, V_2k = V_k^2 - 2 * Q^k
+		U.Mod(U.Mul(U, V), n)
+		V.Mul(V, V).Sub(V, new(big.Int).Lsh(Qk, 1)).Mod(V, n)
+		Qk.Mod(Qk.Mul(Qk, Qk), n)
+		if d.Bit(i) == 1 {
+			// U_2k+1 = (P * U_2k + V_2k) / 2, V_2k+1 = (D * U_2k + P * V_2k) / 2
+			u := half(new(big.Int).Add(new(big.Int).Mul(P, U), V))
+			v := half(new(big.Int).Add(new(big.Int).Mul(Dn, U), new(big.Int).Mul(P, V)))
+			U.Mod(u, n)
+			V.Mod(v, n)
+			Qk.Mod(Qk.Mul(Qk, Qn), n)
+		}
+	}
+	// U_d = 0 mod n or V_d = 0 mod n
+	if U.Sign() == 0 || V.Sign() == 0 {
+		return true
+	}
+	// V_{d*2^r} = 0 mod n for some 0 < r < s
+	for r := 1; r < s; r++ {
+		V.Mul(V, V).Sub(V, new(big.Int).Lsh(Qk, 1)).Mod(V, n)
+		if V.Sign() == 0 {
+			return true
+		}
+		Qk.Mod(Qk.Mul(Qk, Qk), n)
+	}
+	return false
+}
+
+// candidate returns a random odd number of the bits whose two most significant bits are set,
+// so that the product of two candidates has exactly the twice bits.
+func candidate(bits int) (*big.Int, error) {
+	bs := make([]byte, (bits+7)/8)
+	_, err := rand.Read(bs)
+	if err != nil {
+		return nil, err
+	}
+	c := new(big.Int).SetBytes(bs)
+	c.SetBit(c, bits-1, 1)
+	c.SetBit(c, bits-2, 1)
+	c.SetBit(c, 0, 1)
+	// clear the bits over the size
+	for i := c.BitLen() - 1; i >= bits; i-- {
+		c.SetBit(c, i, 0)
+	}
+	return c, nil
+}
+
+// GeneratePrime returns a random prime of the bits whose two most significant bits are set.
+// If safe is true, the prime p is a safe prime, that is (p-1)/2 is also prime.
+// The candidates are searched by goroutines in parallel until ctx is done.
+func GeneratePrime(ctx context.Context, bits int, safe bool) (*big.Int, error) {
+	if bits < 8 {
+		return nil, fmt.Errorf("bits is too small")
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	found := make(chan *big.Int, 1)
+	errs := make(chan error, 1)
+	workers := runtime.NumCPU()
+	for w := 0; w < workers; w++ {
+		go func() {
+			for ctx.Err() == nil {
+				p, err := candidate(bits)
+				if err != nil {
+					select {
+					case errs <- err:
+					default:
+					}
+					return
+				}
+				if safe {
+					// p = 2q + 1 and p = 3 mod 4, so q is odd.
+					p.SetBit(p, 1, 1)
+					q := new(big.Int).Rsh(p, 1)
+					if hasSmallFactor(q) || hasSmallFactor(p) || !millerRabin(q, big.NewInt(2)) || !IsProbablyPrime(p) || !IsProbablyPrime(q) {
+						continue
+					}
+				} else if !IsProbablyPrime(p) {
+					continue
+				}
+				select {
+				case found <- p:
+				default:
+				}
+				return
+			}
+		}()
+	}
+	select {
+	case p := <-found:
+		return p, nil
+	case err := <-errs:
+		return nil, err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,101 @@
+package pailliar_test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/tnakagawa/goref/pailliar"
+)
+
+func TestPseudoprimes(t *testing.T) {
+	two := big.NewInt(2)
+	// strong pseudoprimes to base 2 (OEIS A001262)
+	for _, s := range []string{"2047", "3277", "4033", "4681", "8321", "15841", "29341", "42799", "49141", "52633", "65281", "74665", "80581", "85489", "88357", "90751",
+		// strong pseudoprimes to the bases 2..23 and 2..37
+		"3825123056546413051", "318665857834031151167461", "3317044064679887385961981"} {
+		n, _ := new(big.Int).SetString(s, 10)
+		if !pailliar.MillerRabin(n, two) {
+			t.Errorf("%s is a strong pseudoprime to base 2", s)
+		}
+		if pailliar.IsProbablyPrime(n) {
+			t.Errorf("%s is composite", s)
+		}
+	}
+	// strong Lucas pseudoprimes (OEIS A217255)
+	for _, s := range []string{"5459", "5777", "10877", "16109", "18971", "22499", "24569", "25199", "40309", "58519", "75077", "97439"} {
+		n, _ := new(big.Int).SetString(s, 10)
+		if !pailliar.StrongLucas(n) {
+			t.Errorf("%s is a strong Lucas pseudoprime", s)
+		}
+		if pailliar.MillerRabin(n, two) {
+			t.Errorf("%s is not a strong pseudoprime to base 2", s)
+		}
+		if pailliar.IsProbablyPrime(n) {
+			t.Errorf("%s is composite", s)
+		}
+	}
+	// Carmichael numbers and a square
+	for _, k := range []int64{561, 1105, 1729, 2465, 2821, 6601, 8911, 41041, 62745, 63973, 75361, 101101, 4000037 * 4000037} {
+		if pailliar.IsProbablyPrime(big.NewInt(k)) {
+			t.Errorf("%d is composite", k)
+		}
+	}
+	// primes
+	for _, e := range []uint{61, 89, 107, 127, 521, 607} {
+		m := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), e), big.NewInt(1))
+		if !pailliar.IsProbablyPrime(m) {
+			t.Errorf("2^%d - 1 is prime", e)
+		}
+		if !pailliar.StrongLucas(m) {
+			t.Errorf("2^%d - 1 is a strong Lucas probable prime", e)
+		}
+	}
+	for _, k := range []int64{2, 3, 1999, 2003, 4000037, 1000000007} {
+		if !pailliar.IsProbablyPrime(big.NewInt(k)) {
+			t.Errorf("%d is prime", k)
+		}
+	}
+}
+
+func TestGeneratePrime(t *testing.T) {
+	ctx := context.Background()
+	for _, bits := range []int{64, 256, 512} {
+		p, err := pailliar.GeneratePrime(ctx, bits, false)
+		if err != nil {
+			t.Fatalf("error %v", err)
+		}
+		if p.BitLen() != bits || p.Bit(bits-2) != 1 || !p.ProbablyPrime(20) {
+			t.Errorf("illegal prime %d : %v", bits, p)
+			continue
+		}
+		t.Logf("%3d bits prime : %v", bits, p)
+	}
+	// safe prime
+	p, err := pailliar.GeneratePrime(ctx, 256, true)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	q := new(big.Int).Rsh(p, 1)
+	if p.BitLen() != 256 || !p.ProbablyPrime(20) || !q.ProbablyPrime(20) {
+		t.Errorf("illegal safe prime : %v", p)
+	}
+	t.Logf("256 bits safe prime : %v", p)
+	// cancellation
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	_, _, err = pailliar.KeyGenerationContext(ctx, 4096, true)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("KeyGenerationContext must be canceled : %v", err)
+	}
+	// the size of n
+	pub, _, err := pailliar.KeyGenerationContext(context.Background(), 1024, false)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	if pub.N().BitLen() != 1024 {
+		t.Errorf("illegal size of n : %d", pub.N().BitLen())
+	}
+}
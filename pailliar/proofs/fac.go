@@ -0,0 +1,322 @@
+package proofs
+
+import (
+	"context"
+	"encoding/asn1"
+	"fmt"
+	"math/big"
+
+	"github.com/tnakagawa/goref/pailliar"
+)
+
+// The parameters of the no small factor proof.
+const (
+	// FacL is ℓ, the proof shows that the factors of N are larger than about 2^-ℓ * sqrt(N).
+	FacL = 256
+	// FacEps is ε, the slackness parameter.
+	FacEps = 512
+	// PrmIterations is the number of the challenges of the ring-Pedersen parameters proof,
+	// the soundness error is 2^-PrmIterations.
+	PrmIterations = 80
+)
+
+// RingPedersen is the ring-Pedersen parameters (N̂, s, t), where s = t^λ mod N̂.
+type RingPedersen struct {
+	N *big.Int // N̂
+	S *big.Int // s
+	T *big.Int // t
+}
+
+// RingPedersenSecret is the secret of the ring-Pedersen parameters.
+type RingPedersenSecret struct {
+	Phi    *big.Int // φ(N̂)
+	Lambda *big.Int // λ
+}
+
+// GenerateRingPedersen returns the ring-Pedersen parameters of the bits,
+// where N̂ is the product of two safe primes.
+func GenerateRingPedersen(ctx context.Context, bits int) (*RingPedersen, *RingPedersenSecret, error) {
+	p, err := pailliar.GeneratePrime(ctx, bits/2, true)
+	if err != nil {
+		return nil, nil, err
+	}
+	var q *big.Int
+	for q == nil || q.Cmp(p) == 0 {
+		q, err = pailliar.GeneratePrime(ctx, bits-bits/2, true)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	n := new(big.Int).Mul(p, q)
+	phi := new(big.Int).Mul(new(big.Int).Sub(p, ONE), new(big.Int).Sub(q, ONE))
+	// t = r^2 mod N̂
+	r, err := randUnit(n)
+	if err != nil {
+		return nil, nil, err
+	}
+	t := mulMod(r, r, n)
+	// s = t^λ mod N̂
+	lambda, err := randInt(phi)
+	if err != nil {
+		return nil, nil, err
+	}
+	s := new(big.Int).Exp(t, lambda, n)
+	return &RingPedersen{N: n, S: s, T: t}, &RingPedersenSecret{Phi: phi, Lambda: lambda}, nil
+}
+
+// validate returns an error if the parameters are not in Z*_N̂.
+func (rp *RingPedersen) validate() error {
+	if err := notNil(rp.N, rp.S, rp.T); err != nil {
+		return err
+	}
+	if rp.N.Bit(0) == 0 || !isUnit(rp.S, rp.N) || !isUnit(rp.T, rp.N) {
+		return fmt.Errorf("illegal ring-Pedersen parameters")
+	}
+	return nil
+}
+
+// commit returns s^x * t^y mod N̂.
+func (rp *RingPedersen) commit(x, y *big.Int) (*big.Int, error) {
+	sx, err := exp(rp.S, x, rp.N)
+	if err != nil {
+		return nil, err
+	}
+	ty, err := exp(rp.T, y, rp.N)
+	if err != nil {
+		return nil, err
+	}
+	return mulMod(sx, ty, rp.N), nil
+}
+
+// PrmProof is the ring-Pedersen parameters proof Π^prm,
+// which proves that s is in the subgroup generated by t.
+type PrmProof struct {
+	A []*big.Int // A_i = t^a_i mod N̂
+	Z []*big.Int // z_i = a_i + e_i * λ mod φ(N̂)
+}
+
+// prmChallenges returns the challenge bits e_1..e_m.
+func prmChallenges(rp *RingPedersen, as []*big.Int) []uint {
+	t := newTranscript("prm")
+	t.append(rp.N, rp.S, rp.T)
+	t.append(as...)
+	es := []uint{}
+	for i := 0; i < PrmIterations; i++ {
+		es = append(es, uint(t.challenge(i, big.NewInt(2)).Int64()))
+	}
+	return es
+}
+
+// ProvePrm returns the ring-Pedersen parameters proof.
+func ProvePrm(rp *RingPedersen, sec *RingPedersenSecret) (*PrmProof, error) {
+	as := []*big.Int{}
+	pr := &PrmProof{}
+	for i := 0; i < PrmIterations; i++ {
+		a, err := randInt(sec.Phi)
+		if err != nil {
+			return nil, err
+		}
+		as = append(as, a)
+		pr.A = append(pr.A, new(big.Int).Exp(rp.T, a, rp.N))
+	}
+	for i, e := range prmChallenges(rp, pr.A) {
+		z := new(big.Int).Set(as[i])
+		if e == 1 {
+			z.Add(z, sec.Lambda)
+		}
+		pr.Z = append(pr.Z, z.Mod(z, sec.Phi))
+	}
+	return pr, nil
+}
+
+// Verify verifies the ring-Pedersen parameters proof.
+func (pr *PrmProof) Verify(rp *RingPedersen) error {
+	if err := rp.validate(); err != nil {
+		return err
+	}
+	if len(pr.A) != PrmIterations || len(pr.Z) != PrmIterations {
+		return fmt.Errorf("illegal proof size")
+	}
+	if err := notNil(append(append([]*big.Int{}, pr.A...), pr.Z...)...); err != nil {
+		return err
+	}
+	for i, e := range prmChallenges(rp, pr.A) {
+		// t^z_i = A_i * s^e_i mod N̂
+		rhs := new(big.Int).Mod(pr.A[i], rp.N)
+		if e == 1 {
+			rhs = mulMod(rhs, rp.S, rp.N)
+		}
+		if pr.Z[i].Sign() < 0 || new(big.Int).Exp(rp.T, pr.Z[i], rp.N).Cmp(rhs) != 0 {
+			return fmt.Errorf("%d : t^z_i != A_i * s^e_i mod N̂", i)
+		}
+	}
+	return nil
+}
+
+// MarshalBinary returns the ASN.1 DER encoding of the proof.
+func (pr *PrmProof) MarshalBinary() ([]byte, error) {
+	return asn1.Marshal(*pr)
+}
+
+// UnmarshalBinary sets the proof from the ASN.1 DER encoding.
+func (pr *PrmProof) UnmarshalBinary(data []byte) error {
+	return unmarshalDER(data, pr)
+}
+
+// FacProof is the no small factor proof Π^fac,
+// which proves that N0 = pq with |p|, |q| ≤ 2^ℓ * sqrt(N0) by the ring-Pedersen parameters of the verifier.
+type FacProof struct {
+	P     *big.Int // P = s^p * t^μ mod N̂
+	Q     *big.Int // Q = s^q * t^ν mod N̂
+	A     *big.Int // A = s^α * t^x mod N̂
+	B     *big.Int // B = s^β * t^y mod N̂
+	T     *big.Int // T = Q^α * t^r mod N̂
+	Sigma *big.Int // σ
+	Z1    *big.Int // z1 = α + e * p
+	Z2    *big.Int // z2 = β + e * q
+	W1    *big.Int // w1 = x + e * μ
+	W2    *big.Int // w2 = y + e * ν
+	V     *big.Int // v = r + e * (σ - ν * p)
+}
+
+// facChallenge returns the challenge e in [-2^ℓ, 2^ℓ).
+func facChallenge(n0 *big.Int, rp *RingPedersen, pr *FacProof) *big.Int {
+	t := newTranscript("fac")
+	t.append(n0, rp.N, rp.S, rp.T, pr.P, pr.Q, pr.A, pr.B, pr.T, pr.Sigma)
+	return t.challengeSigned(0, FacL)
+}
+
+// ProveFac returns the no small factor proof of the private key for the ring-Pedersen parameters.
+func ProveFac(pri *pailliar.PrivateKey, rp *RingPedersen) (*FacProof, error) {
+	if err := rp.validate(); err != nil {
+		return nil, err
+	}
+	p, q, n0 := pri.P(), pri.Q(), pri.N()
+	sqrtN0 := new(big.Int).Sqrt(n0)
+	// bounds
+	le := new(big.Int).Lsh(sqrtN0, FacL+FacEps)                       // 2^(ℓ+ε) * sqrt(N0)
+	ln := new(big.Int).Lsh(rp.N, FacL)                                // 2^ℓ * N̂
+	lnn := new(big.Int).Lsh(new(big.Int).Mul(n0, rp.N), FacL)         // 2^ℓ * N0 * N̂
+	lenn := new(big.Int).Lsh(new(big.Int).Mul(n0, rp.N), FacL+FacEps) // 2^(ℓ+ε) * N0 * N̂
+	lnE := new(big.Int).Lsh(rp.N, FacL+FacEps)                        // 2^(ℓ+ε) * N̂
+	rs := []*big.Int{}
+	for _, bound := range []*big.Int{le, le, ln, ln, lnn, lenn, lnE, lnE} {
+		x, err := randSigned(bound)
+		if err != nil {
+			return nil, err
+		}
+		rs = append(rs, x)
+	}
+	alpha, beta, mu, nu, sigma, r, x, y := rs[0], rs[1], rs[2], rs[3], rs[4], rs[5], rs[6], rs[7]
+	pr := &FacProof{Sigma: sigma}
+	var err error
+	if pr.P, err = rp.commit(p, mu); err != nil {
+		return nil, err
+	}
+	if pr.Q, err = rp.commit(q, nu); err != nil {
+		return nil, err
+	}
+	if pr.A, err = rp.commit(alpha, x); err != nil {
+		return nil, err
+	}
+	if pr.B, err = rp.commit(beta, y); err != nil {
+		return nil, err
+	}
+	// T = Q^α * t^r mod N̂
+	qa, err := exp(pr.Q, alpha, rp.N)
+	if err != nil {
+		return nil, err
+	}
+	tr, err := exp(rp.T, r, rp.N)
+	if err != nil {
+		return nil, err
+	}
+	pr.T = mulMod(qa, tr, rp.N)
+	e := facChallenge(n0, rp, pr)
+	// σ̂ = σ - ν * p
+	sigmaHat := new(big.Int).Sub(sigma, new(big.Int).Mul(nu, p))
+	pr.Z1 = new(big.Int).Add(alpha, new(big.Int).Mul(e, p))
+	pr.Z2 = new(big.Int).Add(beta, new(big.Int).Mul(e, q))
+	pr.W1 = new(big.Int).Add(x, new(big.Int).Mul(e, mu))
+	pr.W2 = new(big.Int).Add(y, new(big.Int).Mul(e, nu))
+	pr.V = new(big.Int).Add(r, new(big.Int).Mul(e, sigmaHat))
+	return pr, nil
+}
+
+// Verify verifies the no small factor proof of the public key for the ring-Pedersen parameters.
+func (pr *FacProof) Verify(pub *pailliar.PublicKey, rp *RingPedersen) error {
+	if err := rp.validate(); err != nil {
+		return err
+	}
+	if err := notNil(pr.P, pr.Q, pr.A, pr.B, pr.T, pr.Sigma, pr.Z1, pr.Z2, pr.W1, pr.W2, pr.V); err != nil {
+		return err
+	}
+	for _, x := range []*big.Int{pr.P, pr.Q, pr.A, pr.B, pr.T} {
+		if !isUnit(x, rp.N) {
+			return fmt.Errorf("the commitment is not in Z*_N̂")
+		}
+	}
+	n0 := pub.N()
+	e := facChallenge(n0, rp, pr)
+	// R = s^N0 * t^σ mod N̂
+	R, err := rp.commit(n0, pr.Sigma)
+	if err != nil {
+		return err
+	}
+	// s^z1 * t^w1 = A * P^e mod N̂
+	lhs, err := rp.commit(pr.Z1, pr.W1)
+	if err != nil {
+		return err
+	}
+	pe, err := exp(pr.P, e, rp.N)
+	if err != nil {
+		return err
+	}
+	if lhs.Cmp(mulMod(pr.A, pe, rp.N)) != 0 {
+		return fmt.Errorf("s^z1 * t^w1 != A * P^e mod N̂")
+	}
+	// s^z2 * t^w2 = B * Q^e mod N̂
+	lhs, err = rp.commit(pr.Z2, pr.W2)
+	if err != nil {
+		return err
+	}
+	qe, err := exp(pr.Q, e, rp.N)
+	if err != nil {
+		return err
+	}
+	if lhs.Cmp(mulMod(pr.B, qe, rp.N)) != 0 {
+		return fmt.Errorf("s^z2 * t^w2 != B * Q^e mod N̂")
+	}
+	// Q^z1 * t^v = T * R^e mod N̂
+	qz, err := exp(pr.Q, pr.Z1, rp.N)
+	if err != nil {
+		return err
+	}
+	tv, err := exp(rp.T, pr.V, rp.N)
+	if err != nil {
+		return err
+	}
+	re, err := exp(R, e, rp.N)
+	if err != nil {
+		return err
+	}
+	if mulMod(qz, tv, rp.N).Cmp(mulMod(pr.T, re, rp.N)) != 0 {
+		return fmt.Errorf("Q^z1 * t^v != T * R^e mod N̂")
+	}
+	// z1, z2 ∈ ±2^(ℓ+ε) * sqrt(N0)
+	bound := new(big.Int).Lsh(new(big.Int).Sqrt(n0), FacL+FacEps)
+	if new(big.Int).Abs(pr.Z1).Cmp(bound) > 0 || new(big.Int).Abs(pr.Z2).Cmp(bound) > 0 {
+		return fmt.Errorf("z1 or z2 is out of range")
+	}
+	return nil
+}
+
+// MarshalBinary returns the ASN.1 DER encoding of the proof.
+func (pr *FacProof) MarshalBinary() ([]byte, error) {
+	return asn1.Marshal(*pr)
+}
+
+// UnmarshalBinary sets the proof from the ASN.1 DER encoding.
+func (pr *FacProof) UnmarshalBinary(data []byte) error {
+	return unmarshalDER(data, pr)
+}
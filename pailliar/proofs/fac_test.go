@@ -0,0 +1,77 @@
+package proofs_test
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/tnakagawa/goref/pailliar"
+	"github.com/tnakagawa/goref/pailliar/proofs"
+)
+
+func TestPrmProof(t *testing.T) {
+	rp, sec, err := proofs.GenerateRingPedersen(context.Background(), 512)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	pr, err := proofs.ProvePrm(rp, sec)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	err = pr.Verify(rp)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	roundTrip(t, pr, &proofs.PrmProof{}, &proofs.PrmProof{}, func(p proof) error {
+		return p.(*proofs.PrmProof).Verify(rp)
+	})
+	// s is not generated by t
+	bad := *rp
+	bad.S = new(big.Int).Add(rp.S, big.NewInt(1))
+	if pr.Verify(&bad) == nil {
+		t.Errorf("tampered s : Verify must fail")
+	}
+}
+
+func TestFacProof(t *testing.T) {
+	pub, pri := testKey(t)
+	rp, _, err := proofs.GenerateRingPedersen(context.Background(), 512)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	pr, err := proofs.ProveFac(pri, rp)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	err = pr.Verify(pub, rp)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	roundTrip(t, pr, &proofs.FacProof{}, &proofs.FacProof{}, func(p proof) error {
+		return p.(*proofs.FacProof).Verify(pub, rp)
+	})
+	// tampered proofs
+	bad := *pr
+	bad.Z1 = new(big.Int).Add(pr.Z1, big.NewInt(1))
+	if bad.Verify(pub, rp) == nil {
+		t.Errorf("tampered z1 : Verify must fail")
+	}
+	bad = *pr
+	bad.V = new(big.Int).Neg(pr.V)
+	if bad.Verify(pub, rp) == nil {
+		t.Errorf("tampered v : Verify must fail")
+	}
+	bad = *pr
+	bad.T = nil
+	if bad.Verify(pub, rp) == nil {
+		t.Errorf("missing T : Verify must fail")
+	}
+	// the other key
+	pub2, _, err := pailliar.KeyGeneration(1024)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	if pr.Verify(pub2, rp) == nil {
+		t.Errorf("other key : Verify must fail")
+	}
+}
@@ -0,0 +1,159 @@
+package proofs
+
+import (
+	"encoding/asn1"
+	"fmt"
+	"math/big"
+
+	"github.com/tnakagawa/goref/pailliar"
+)
+
+// ModIterations is the number of the challenges of the Paillier-Blum modulus proof,
+// the soundness error is 2^-ModIterations.
+const ModIterations = 80
+
+// ModProof is the Paillier-Blum modulus proof Π^mod,
+// which proves that N = pq with the primes p ≡ q ≡ 3 mod 4 and gcd(N, φ(N)) = 1.
+type ModProof struct {
+	W *big.Int   // w with the Jacobi symbol (w/N) = -1
+	X []*big.Int // x_i = ((-1)^a_i * w^b_i * y_i)^(1/4) mod N
+	A []bool     // a_i
+	B []bool     // b_i
+	Z []*big.Int // z_i = y_i^(N^-1 mod φ(N)) mod N
+}
+
+// modChallenges returns the challenges y_1..y_m in Z_N.
+func modChallenges(n, w *big.Int) []*big.Int {
+	t := newTranscript("mod")
+	t.append(n, w)
+	ys := []*big.Int{}
+	for i := 0; i < ModIterations; i++ {
+		ys = append(ys, t.challenge(i, n))
+	}
+	return ys
+}
+
+// fourthRoot returns the fourth root of the quadratic residue y mod p, where p ≡ 3 mod 4.
+func fourthRoot(y, p *big.Int) *big.Int {
+	// y^((p+1)/4) is the square root which is also a quadratic residue.
+	e := new(big.Int).Add(p, ONE)
+	e.Rsh(e, 2)
+	x := new(big.Int).Exp(y, e, p)
+	return x.Exp(x, e, p)
+}
+
+// ProveMod returns the Paillier-Blum modulus proof of the private key.
+// The primes of the default keys are not always 3 mod 4, so that the key must be generated
+// by pailliar.KeyGenerationContext(ctx, bits, true), whose safe primes p = 2p' + 1 are 3 mod 4.
+func ProveMod(pri *pailliar.PrivateKey) (*ModProof, error) {
+	p, q, n := pri.P(), pri.Q(), pri.N()
+	// p ≡ q ≡ 3 mod 4
+	if p.Bit(0) != 1 || p.Bit(1) != 1 || q.Bit(0) != 1 || q.Bit(1) != 1 {
+		return nil, fmt.Errorf("p or q is not 3 mod 4")
+	}
+	phi := new(big.Int).Mul(new(big.Int).Sub(p, ONE), new(big.Int).Sub(q, ONE))
+	nInv := new(big.Int).ModInverse(n, phi)
+	if nInv == nil {
+		return nil, fmt.Errorf("gcd(N, φ(N)) != 1")
+	}
+	// Sample w with the Jacobi symbol (w/N) = -1.
+	var w *big.Int
+	for {
+		x, err := randUnit(n)
+		if err != nil {
+			return nil, err
+		}
+		if big.Jacobi(x, n) == -1 {
+			w = x
+			break
+		}
+	}
+	pr := &ModProof{W: w}
+	minus := new(big.Int).Sub(n, ONE)
+	qInv := new(big.Int).ModInverse(q, p)
+	for _, y := range modChallenges(n, w) {
+		// z_i = y_i^(N^-1 mod φ(N)) mod N
+		pr.Z = append(pr.Z, new(big.Int).Exp(y, nInv, n))
+		// Find the unique a_i, b_i such that (-1)^a_i * w^b_i * y_i is a quadratic residue mod N.
+		found := false
+		for _, a := range []bool{false, true} {
+			for _, b := range []bool{false, true} {
+				yd := new(big.Int).Set(y)
+				if a {
+					yd = mulMod(yd, minus, n)
+				}
+				if b {
+					yd = mulMod(yd, w, n)
+				}
+				yp := new(big.Int).Mod(yd, p)
+				yq := new(big.Int).Mod(yd, q)
+				if found || big.Jacobi(yp, p) != 1 || big.Jacobi(yq, q) != 1 {
+					continue
+				}
+				// x_i = y'_i^(1/4) mod N by the CRT
+				xp := fourthRoot(yp, p)
+				xq := fourthRoot(yq, q)
+				x := new(big.Int).Sub(xp, xq)
+				x.Mod(x.Mul(x, qInv), p)
+				x.Add(x.Mul(x, q), xq)
+				pr.X = append(pr.X, x)
+				pr.A = append(pr.A, a)
+				pr.B = append(pr.B, b)
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("y_i is not in Z*_N")
+		}
+	}
+	return pr, nil
+}
+
+// Verify verifies the Paillier-Blum modulus proof of the public key.
+func (pr *ModProof) Verify(pub *pailliar.PublicKey) error {
+	n := pub.N()
+	if len(pr.X) != ModIterations || len(pr.A) != ModIterations || len(pr.B) != ModIterations || len(pr.Z) != ModIterations {
+		return fmt.Errorf("illegal proof size")
+	}
+	if err := notNil(append(append([]*big.Int{pr.W}, pr.X...), pr.Z...)...); err != nil {
+		return err
+	}
+	// N is an odd composite number
+	if n.Bit(0) == 0 || pailliar.IsProbablyPrime(n) {
+		return fmt.Errorf("N is even or prime")
+	}
+	// (w/N) = -1
+	if pr.W.Sign() <= 0 || pr.W.Cmp(n) >= 0 || big.Jacobi(pr.W, n) != -1 {
+		return fmt.Errorf("(w/N) != -1")
+	}
+	minus := new(big.Int).Sub(n, ONE)
+	four := big.NewInt(4)
+	for i, y := range modChallenges(n, pr.W) {
+		// z_i^N = y_i mod N
+		if pr.Z[i].Sign() < 0 || pr.Z[i].Cmp(n) >= 0 || new(big.Int).Exp(pr.Z[i], n, n).Cmp(y) != 0 {
+			return fmt.Errorf("%d : z_i^N != y_i mod N", i)
+		}
+		// x_i^4 = (-1)^a_i * w^b_i * y_i mod N
+		yd := new(big.Int).Set(y)
+		if pr.A[i] {
+			yd = mulMod(yd, minus, n)
+		}
+		if pr.B[i] {
+			yd = mulMod(yd, pr.W, n)
+		}
+		if pr.X[i].Sign() < 0 || pr.X[i].Cmp(n) >= 0 || new(big.Int).Exp(pr.X[i], four, n).Cmp(yd) != 0 {
+			return fmt.Errorf("%d : x_i^4 != (-1)^a_i * w^b_i * y_i mod N", i)
+		}
+	}
+	return nil
+}
+
+// MarshalBinary returns the ASN.1 DER encoding of the proof.
+func (pr *ModProof) MarshalBinary() ([]byte, error) {
+	return asn1.Marshal(*pr)
+}
+
+// UnmarshalBinary sets the proof from the ASN.1 DER encoding.
+func (pr *ModProof) UnmarshalBinary(data []byte) error {
+	return unmarshalDER(data, pr)
+}
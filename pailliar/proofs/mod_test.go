@@ -0,0 +1,57 @@
+package proofs_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/tnakagawa/goref/pailliar"
+	"github.com/tnakagawa/goref/pailliar/proofs"
+)
+
+func TestModProof(t *testing.T) {
+	pub, pri := testKey(t)
+	pr, err := proofs.ProveMod(pri)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	err = pr.Verify(pub)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	roundTrip(t, pr, &proofs.ModProof{}, &proofs.ModProof{}, func(p proof) error {
+		return p.(*proofs.ModProof).Verify(pub)
+	})
+	// tampered proofs
+	bad := *pr
+	bad.X = append([]*big.Int{new(big.Int).Add(pr.X[0], big.NewInt(1))}, pr.X[1:]...)
+	if bad.Verify(pub) == nil {
+		t.Errorf("tampered x : Verify must fail")
+	}
+	bad = *pr
+	bad.A = append([]bool{!pr.A[0]}, pr.A[1:]...)
+	if bad.Verify(pub) == nil {
+		t.Errorf("tampered a : Verify must fail")
+	}
+	bad = *pr
+	bad.Z = pr.Z[1:]
+	if bad.Verify(pub) == nil {
+		t.Errorf("short z : Verify must fail")
+	}
+	// the other key
+	pub2, _, err := pailliar.KeyGeneration(1024)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	if pr.Verify(pub2) == nil {
+		t.Errorf("other key : Verify must fail")
+	}
+	// p = 1 mod 4
+	_, pri3, err := pailliar.KeyFromPrimes(big.NewInt(1000037), big.NewInt(1000039))
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	_, err = proofs.ProveMod(pri3)
+	if err == nil {
+		t.Errorf("p = 1 mod 4 : ProveMod must fail")
+	}
+}
@@ -0,0 +1,182 @@
+// Package proofs is the non-interactive zero-knowledge proofs for pailliar keys and ciphertexts.
+// The interactive protocols are made non-interactive by the Fiat–Shamir transform with SHA256.
+//
+// The proofs of the keys follow UC Non-Interactive, Proactive, Threshold ECDSA with Identifiable Aborts
+// (Canetti, Gennaro, Goldfeder, Makriyannis, Peled, https://eprint.iacr.org/2021/060):
+// the Paillier-Blum modulus proof Π^mod, the ring-Pedersen parameters proof Π^prm and the no small factor proof Π^fac.
+//
+// The proofs of the ciphertexts are the proofs of N-th residuosity (Damgård, Jurik, https://doi.org/10.1007/3-540-44586-2_9):
+// the proof that a ciphertext encrypts zero, the proof that a ciphertext encrypts one of the given messages,
+// and the range proof by the bit decomposition.
+//
+// All proofs are serializable with encoding/json and with ASN.1 DER by MarshalBinary and UnmarshalBinary.
+package proofs
+
+import (
+	"crypto/rand"
+	"encoding/asn1"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+
+	"github.com/tnakagawa/goref/pailliar"
+	"github.com/tnakagawa/goref/sha256"
+)
+
+// ONE is 1 of *big.Int .
+var ONE = big.NewInt(1)
+
+// transcript is the transcript of the Fiat–Shamir transform.
+type transcript struct {
+	data []byte
+}
+
+// newTranscript returns the transcript of the proof with the tag.
+func newTranscript(tag string) *transcript {
+	t := &transcript{}
+	t.appendBytes([]byte("goref/pailliar/proofs/" + tag))
+	return t
+}
+
+// appendBytes appends the length-prefixed bytes.
+func (t *transcript) appendBytes(bs []byte) {
+	l := make([]byte, 8)
+	binary.BigEndian.PutUint64(l, uint64(len(bs)))
+	t.data = append(append(t.data, l...), bs...)
+}
+
+// append appends the integers with their signs.
+func (t *transcript) append(xs ...*big.Int) {
+	for _, x := range xs {
+		sign := byte(0)
+		if x.Sign() < 0 {
+			sign = 1
+		}
+		t.appendBytes(append([]byte{sign}, x.Bytes()...))
+	}
+}
+
+// stream returns the bytes of the size generated from the transcript with the index i and the counter.
+func (t *transcript) stream(i, counter uint32, size int) []byte {
+	out := []byte{}
+	for block := uint32(0); len(out) < size; block++ {
+		b := make([]byte, 12)
+		binary.BigEndian.PutUint32(b[0:], i)
+		binary.BigEndian.PutUint32(b[4:], counter)
+		binary.BigEndian.PutUint32(b[8:], block)
+		out = append(out, sha256.Digest(append(append([]byte{}, t.data...), b...))...)
+	}
+	return out[:size]
+}
+
+// challenge returns the i-th challenge in the range [0, max) by the rejection sampling.
+func (t *transcript) challenge(i int, max *big.Int) *big.Int {
+	bits := max.BitLen()
+	for counter := uint32(0); ; counter++ {
+		bs := t.stream(uint32(i), counter, (bits+7)/8)
+		// clear the bits over the size
+		if bits%8 != 0 {
+			bs[0] &= byte(1<<uint(bits%8)) - 1
+		}
+		x := new(big.Int).SetBytes(bs)
+		if x.Cmp(max) < 0 {
+			return x
+		}
+	}
+}
+
+// challengeSigned returns the i-th challenge in the range [-2^bits, 2^bits).
+func (t *transcript) challengeSigned(i int, bits uint) *big.Int {
+	x := t.challenge(i, new(big.Int).Lsh(ONE, bits+1))
+	return x.Sub(x, new(big.Int).Lsh(ONE, bits))
+}
+
+// randInt returns a random integer in the range [0, max).
+func randInt(max *big.Int) (*big.Int, error) {
+	return rand.Int(rand.Reader, max)
+}
+
+// randSigned returns a random integer in the range [-bound, bound].
+func randSigned(bound *big.Int) (*big.Int, error) {
+	x, err := rand.Int(rand.Reader, new(big.Int).Add(new(big.Int).Lsh(bound, 1), ONE))
+	if err != nil {
+		return nil, err
+	}
+	return x.Sub(x, bound), nil
+}
+
+// randUnit returns a random integer in Z*_n.
+func randUnit(n *big.Int) (*big.Int, error) {
+	for {
+		x, err := rand.Int(rand.Reader, n)
+		if err != nil {
+			return nil, err
+		}
+		if x.Sign() > 0 && new(big.Int).GCD(nil, nil, x, n).Cmp(ONE) == 0 {
+			return x, nil
+		}
+	}
+}
+
+// isUnit returns whether x is in Z*_n.
+func isUnit(x, n *big.Int) bool {
+	return x != nil && x.Sign() > 0 && x.Cmp(n) < 0 && new(big.Int).GCD(nil, nil, x, n).Cmp(ONE) == 0
+}
+
+// exp returns x^y mod m, where y may be negative.
+func exp(x, y, m *big.Int) (*big.Int, error) {
+	z := new(big.Int).Exp(x, y, m)
+	if z == nil {
+		return nil, fmt.Errorf("x is not invertible")
+	}
+	return z, nil
+}
+
+// mulMod returns x * y mod m.
+func mulMod(x, y, m *big.Int) *big.Int {
+	z := new(big.Int).Mul(x, y)
+	return z.Mod(z, m)
+}
+
+// notNil returns an error if one of the integers is nil.
+func notNil(xs ...*big.Int) error {
+	for _, x := range xs {
+		if x == nil {
+			return fmt.Errorf("missing parameters")
+		}
+	}
+	return nil
+}
+
+// unmarshalDER unmarshals the ASN.1 DER encoding without trailing data.
+func unmarshalDER(data []byte, v interface{}) error {
+	rest, err := asn1.Unmarshal(data, v)
+	if err != nil {
+		return err
+	}
+	if len(rest) != 0 {
+		return fmt.Errorf("trailing data")
+	}
+	return nil
+}
+
+// Encrypt returns the ciphertext c = g^m * r^n mod n^2 and the randomness r,
+// which is the witness of the proofs of the ciphertext.
+func Encrypt(pub *pailliar.PublicKey, m *big.Int) (*big.Int, *big.Int, error) {
+	r, err := randUnit(pub.N())
+	if err != nil {
+		return nil, nil, err
+	}
+	c, err := EncryptWithR(pub, m, r)
+	if err != nil {
+		return nil, nil, err
+	}
+	return c, r, nil
+}
+
+// EncryptWithR returns the ciphertext c = g^m * r^n mod n^2 for the randomness r.
+func EncryptWithR(pub *pailliar.PublicKey, m, r *big.Int) (*big.Int, error) {
+	n := pub.N()
+	n2 := new(big.Int).Mul(n, n)
+	return pub.EncryptionWithRn(m, new(big.Int).Exp(r, n, n2))
+}
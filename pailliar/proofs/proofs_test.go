@@ -0,0 +1,91 @@
+package proofs_test
+
+import (
+	"context"
+	"encoding"
+	"encoding/json"
+	"testing"
+
+	"github.com/tnakagawa/goref/pailliar"
+	"github.com/tnakagawa/goref/pailliar/proofs"
+)
+
+var (
+	testPub *pailliar.PublicKey
+	testPri *pailliar.PrivateKey
+)
+
+// testKey returns the 1024 bits key whose primes are safe primes, so that they are 3 mod 4.
+func testKey(t *testing.T) (*pailliar.PublicKey, *pailliar.PrivateKey) {
+	if testPri != nil {
+		return testPub, testPri
+	}
+	var err error
+	testPub, testPri, err = pailliar.KeyGenerationContext(context.Background(), 1024, true)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	return testPub, testPri
+}
+
+// proof is the serializable proof.
+type proof interface {
+	encoding.BinaryMarshaler
+	encoding.BinaryUnmarshaler
+}
+
+// roundTrip encodes pr by DER and JSON, decodes them into der and js, and checks that the decoded proofs are verified.
+func roundTrip(t *testing.T, pr, der, js proof, verify func(proof) error) {
+	bs, err := pr.MarshalBinary()
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	t.Logf("DER %d bytes", len(bs))
+	err = der.UnmarshalBinary(bs)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	err = verify(der)
+	if err != nil {
+		t.Errorf("DER : error %v", err)
+	}
+	if der.UnmarshalBinary(append(bs, 0x00)) == nil {
+		t.Errorf("UnmarshalBinary must fail with trailing data")
+	}
+	bs, err = json.Marshal(pr)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	t.Logf("JSON %d bytes", len(bs))
+	err = json.Unmarshal(bs, js)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	err = verify(js)
+	if err != nil {
+		t.Errorf("JSON : error %v", err)
+	}
+}
+
+func TestEncrypt(t *testing.T) {
+	pub, pri := testKey(t)
+	m := pailliar.Rnd(pub.N())
+	c, r, err := proofs.Encrypt(pub, m)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	x, err := pri.Decryption(c)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	if x.Cmp(m) != 0 {
+		t.Fatalf("m != x : %v != %v", m, x)
+	}
+	c2, err := proofs.EncryptWithR(pub, m, r)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	if c.Cmp(c2) != 0 {
+		t.Fatalf("c != c2 : %x != %x", c, c2)
+	}
+}
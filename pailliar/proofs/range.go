@@ -0,0 +1,119 @@
+package proofs
+
+import (
+	"encoding/asn1"
+	"fmt"
+	"math/big"
+
+	"github.com/tnakagawa/goref/pailliar"
+)
+
+// bits are the messages of the bit ciphertexts.
+var bits = []*big.Int{big.NewInt(0), big.NewInt(1)}
+
+// RangeProof is the range proof that the ciphertext c encrypts the message in [0, 2^l).
+// The message m = Σ b_i * 2^i is decomposed into the bits, each bit is encrypted to c_i with the proof of b_i ∈ {0, 1},
+// and c * Π c_i^-2^i mod N^2 is proved to encrypt zero.
+type RangeProof struct {
+	C    []*big.Int   // c_i = g^b_i * r_i^N mod N^2
+	Bits []OneOfProof // the proofs of b_i ∈ {0, 1}
+	Zero ZeroProof    // the proof that c * Π c_i^-2^i mod N^2 encrypts zero
+}
+
+// checkRange returns an error if 2^l is not less than N.
+func checkRange(pub *pailliar.PublicKey, l int) error {
+	if l <= 0 || l >= pub.N().BitLen() {
+		return fmt.Errorf("illegal range 2^%d", l)
+	}
+	return nil
+}
+
+// rangeResidue returns c * Π c_i^-2^i mod N^2.
+func rangeResidue(pub *pailliar.PublicKey, c *big.Int, cs []*big.Int) (*big.Int, error) {
+	n := pub.N()
+	n2 := new(big.Int).Mul(n, n)
+	d := new(big.Int).Set(c)
+	for i, ci := range cs {
+		x, err := exp(ci, new(big.Int).Neg(new(big.Int).Lsh(ONE, uint(i))), n2)
+		if err != nil {
+			return nil, err
+		}
+		d = mulMod(d, x, n2)
+	}
+	return d, nil
+}
+
+// ProveRange returns the range proof that c = g^m * r^N mod N^2 encrypts m in [0, 2^l).
+func ProveRange(pub *pailliar.PublicKey, c, m, r *big.Int, l int) (*RangeProof, error) {
+	if err := checkRange(pub, l); err != nil {
+		return nil, err
+	}
+	if m.Sign() < 0 || m.BitLen() > l {
+		return nil, fmt.Errorf("m is out of range")
+	}
+	n := pub.N()
+	pr := &RangeProof{}
+	// r' = r * Π r_i^-2^i mod N
+	rd := new(big.Int).Set(r)
+	for i := 0; i < l; i++ {
+		b := int(m.Bit(i))
+		ci, ri, err := Encrypt(pub, bits[b])
+		if err != nil {
+			return nil, err
+		}
+		bp, err := ProveOneOf(pub, ci, bits, b, ri)
+		if err != nil {
+			return nil, err
+		}
+		pr.C = append(pr.C, ci)
+		pr.Bits = append(pr.Bits, *bp)
+		x, err := exp(ri, new(big.Int).Neg(new(big.Int).Lsh(ONE, uint(i))), n)
+		if err != nil {
+			return nil, err
+		}
+		rd = mulMod(rd, x, n)
+	}
+	d, err := rangeResidue(pub, c, pr.C)
+	if err != nil {
+		return nil, err
+	}
+	zp, err := ProveZero(pub, d, rd)
+	if err != nil {
+		return nil, err
+	}
+	pr.Zero = *zp
+	return pr, nil
+}
+
+// Verify verifies the range proof that c encrypts the message in [0, 2^l).
+func (pr *RangeProof) Verify(pub *pailliar.PublicKey, c *big.Int, l int) error {
+	if err := checkRange(pub, l); err != nil {
+		return err
+	}
+	if len(pr.C) != l || len(pr.Bits) != l {
+		return fmt.Errorf("illegal proof size")
+	}
+	if err := notNil(append([]*big.Int{c}, pr.C...)...); err != nil {
+		return err
+	}
+	for i := range pr.C {
+		if err := pr.Bits[i].Verify(pub, pr.C[i], bits); err != nil {
+			return fmt.Errorf("bit %d : %v", i, err)
+		}
+	}
+	d, err := rangeResidue(pub, c, pr.C)
+	if err != nil {
+		return err
+	}
+	return pr.Zero.Verify(pub, d)
+}
+
+// MarshalBinary returns the ASN.1 DER encoding of the proof.
+func (pr *RangeProof) MarshalBinary() ([]byte, error) {
+	return asn1.Marshal(*pr)
+}
+
+// UnmarshalBinary sets the proof from the ASN.1 DER encoding.
+func (pr *RangeProof) UnmarshalBinary(data []byte) error {
+	return unmarshalDER(data, pr)
+}
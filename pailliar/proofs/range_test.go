@@ -0,0 +1,66 @@
+package proofs_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/tnakagawa/goref/pailliar/proofs"
+)
+
+func TestRangeProof(t *testing.T) {
+	pub, _ := testKey(t)
+	const l = 32
+	for _, m := range []*big.Int{big.NewInt(0), big.NewInt(1), big.NewInt(123456789), big.NewInt(1<<l - 1)} {
+		c, r, err := proofs.Encrypt(pub, m)
+		if err != nil {
+			t.Fatalf("error %v", err)
+		}
+		pr, err := proofs.ProveRange(pub, c, m, r, l)
+		if err != nil {
+			t.Fatalf("%v : error %v", m, err)
+		}
+		err = pr.Verify(pub, c, l)
+		if err != nil {
+			t.Fatalf("%v : error %v", m, err)
+		}
+		roundTrip(t, pr, &proofs.RangeProof{}, &proofs.RangeProof{}, func(p proof) error {
+			return p.(*proofs.RangeProof).Verify(pub, c, l)
+		})
+		if pr.Verify(pub, c, l-1) == nil {
+			t.Errorf("%v : other range : Verify must fail", m)
+		}
+	}
+	// out of range
+	m := big.NewInt(1 << l)
+	c, r, err := proofs.Encrypt(pub, m)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	_, err = proofs.ProveRange(pub, c, m, r, l)
+	if err == nil {
+		t.Errorf("out of range : ProveRange must fail")
+	}
+	// the proof of the other ciphertext
+	m = big.NewInt(5)
+	c, r, err = proofs.Encrypt(pub, m)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	pr, err := proofs.ProveRange(pub, c, m, r, l)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	c2, _, err := proofs.Encrypt(pub, m)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	if pr.Verify(pub, c2, l) == nil {
+		t.Errorf("other ciphertext : Verify must fail")
+	}
+	// replace the bit ciphertext
+	bad := *pr
+	bad.C = append([]*big.Int{pr.C[1]}, pr.C[1:]...)
+	if bad.Verify(pub, c, l) == nil {
+		t.Errorf("tampered c_i : Verify must fail")
+	}
+}
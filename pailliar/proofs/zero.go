@@ -0,0 +1,233 @@
+package proofs
+
+import (
+	"encoding/asn1"
+	"fmt"
+	"math/big"
+
+	"github.com/tnakagawa/goref/pailliar"
+)
+
+// ChallengeBits is the bits of the challenges of the proofs of the ciphertexts,
+// it must be less than the bits of the factors of N.
+const ChallengeBits = 256
+
+// MinModulusBits is the minimum bits of N of the proofs of the ciphertexts,
+// so that the factors of N of the half bits are longer than 2 * ChallengeBits.
+const MinModulusBits = 4 * ChallengeBits
+
+// checkModulus returns an error if N is too short for the challenges of ChallengeBits,
+// because the prover who knows the factors less than 2^ChallengeBits can answer the challenges without the witness.
+func checkModulus(pub *pailliar.PublicKey) error {
+	if pub.N().BitLen() < MinModulusBits {
+		return fmt.Errorf("N is too short : %d < %d bits", pub.N().BitLen(), MinModulusBits)
+	}
+	return nil
+}
+
+// ZeroProof is the proof that the ciphertext c encrypts zero,
+// that is c = r^N mod N^2 is an N-th residue.
+type ZeroProof struct {
+	A *big.Int // a = ρ^N mod N^2
+	Z *big.Int // z = ρ * r^e mod N
+}
+
+// zeroChallenge returns the challenge e in [0, 2^ChallengeBits).
+func zeroChallenge(pub *pailliar.PublicKey, c, a *big.Int) *big.Int {
+	t := newTranscript("zero")
+	t.append(pub.N(), pub.G(), c, a)
+	return t.challenge(0, new(big.Int).Lsh(ONE, ChallengeBits))
+}
+
+// ProveZero returns the proof that c = r^N mod N^2 encrypts zero.
+func ProveZero(pub *pailliar.PublicKey, c, r *big.Int) (*ZeroProof, error) {
+	n := pub.N()
+	n2 := new(big.Int).Mul(n, n)
+	if new(big.Int).Exp(r, n, n2).Cmp(c) != 0 {
+		return nil, fmt.Errorf("c != r^N mod N^2")
+	}
+	rho, err := randUnit(n)
+	if err != nil {
+		return nil, err
+	}
+	a := new(big.Int).Exp(rho, n, n2)
+	e := zeroChallenge(pub, c, a)
+	z := mulMod(rho, new(big.Int).Exp(r, e, n), n)
+	return &ZeroProof{A: a, Z: z}, nil
+}
+
+// Verify verifies the proof that c encrypts zero.
+func (pr *ZeroProof) Verify(pub *pailliar.PublicKey, c *big.Int) error {
+	if err := notNil(pr.A, pr.Z, c); err != nil {
+		return err
+	}
+	if err := checkModulus(pub); err != nil {
+		return err
+	}
+	n := pub.N()
+	n2 := new(big.Int).Mul(n, n)
+	if !isUnit(c, n2) || !isUnit(pr.A, n2) || !isUnit(pr.Z, n) {
+		return fmt.Errorf("c, a or z is not a unit")
+	}
+	e := zeroChallenge(pub, c, pr.A)
+	// z^N = a * c^e mod N^2
+	if new(big.Int).Exp(pr.Z, n, n2).Cmp(mulMod(pr.A, new(big.Int).Exp(c, e, n2), n2)) != 0 {
+		return fmt.Errorf("z^N != a * c^e mod N^2")
+	}
+	return nil
+}
+
+// MarshalBinary returns the ASN.1 DER encoding of the proof.
+func (pr *ZeroProof) MarshalBinary() ([]byte, error) {
+	return asn1.Marshal(*pr)
+}
+
+// UnmarshalBinary sets the proof from the ASN.1 DER encoding.
+func (pr *ZeroProof) UnmarshalBinary(data []byte) error {
+	return unmarshalDER(data, pr)
+}
+
+// OneOfProof is the proof that the ciphertext c encrypts one of the messages m_0..m_k-1,
+// that is one of u_j = c * g^-m_j mod N^2 is an N-th residue.
+// It is the OR composition of the proofs of N-th residuosity by Cramer, Damgård and Schoenmakers,
+// where the challenges e_j sum up to the challenge e modulo 2^ChallengeBits.
+type OneOfProof struct {
+	A []*big.Int // a_j
+	E []*big.Int // e_j
+	Z []*big.Int // z_j
+}
+
+// oneOfStatements returns u_j = c * g^-m_j mod N^2.
+func oneOfStatements(pub *pailliar.PublicKey, c *big.Int, ms []*big.Int) ([]*big.Int, error) {
+	n := pub.N()
+	n2 := new(big.Int).Mul(n, n)
+	us := []*big.Int{}
+	for _, m := range ms {
+		// g^m mod N^2
+		gm, err := pub.EncryptionWithRn(m, ONE)
+		if err != nil {
+			return nil, err
+		}
+		gmInv := new(big.Int).ModInverse(gm, n2)
+		if gmInv == nil {
+			return nil, fmt.Errorf("g^m is not invertible")
+		}
+		us = append(us, mulMod(c, gmInv, n2))
+	}
+	return us, nil
+}
+
+// oneOfChallenge returns the challenge e in [0, 2^ChallengeBits).
+func oneOfChallenge(pub *pailliar.PublicKey, c *big.Int, ms, as []*big.Int) *big.Int {
+	t := newTranscript("oneof")
+	t.append(pub.N(), pub.G(), c)
+	t.append(ms...)
+	t.append(as...)
+	return t.challenge(0, new(big.Int).Lsh(ONE, ChallengeBits))
+}
+
+// ProveOneOf returns the proof that c = g^m_i * r^N mod N^2 encrypts one of the messages ms.
+func ProveOneOf(pub *pailliar.PublicKey, c *big.Int, ms []*big.Int, i int, r *big.Int) (*OneOfProof, error) {
+	if i < 0 || i >= len(ms) {
+		return nil, fmt.Errorf("illegal index %d", i)
+	}
+	n := pub.N()
+	n2 := new(big.Int).Mul(n, n)
+	us, err := oneOfStatements(pub, c, ms)
+	if err != nil {
+		return nil, err
+	}
+	if new(big.Int).Exp(r, n, n2).Cmp(us[i]) != 0 {
+		return nil, fmt.Errorf("c != g^m_i * r^N mod N^2")
+	}
+	mod := new(big.Int).Lsh(ONE, ChallengeBits)
+	k := len(ms)
+	pr := &OneOfProof{A: make([]*big.Int, k), E: make([]*big.Int, k), Z: make([]*big.Int, k)}
+	var rho *big.Int
+	for j := range ms {
+		if j == i {
+			// a_i = ρ^N mod N^2
+			rho, err = randUnit(n)
+			if err != nil {
+				return nil, err
+			}
+			pr.A[j] = new(big.Int).Exp(rho, n, n2)
+			continue
+		}
+		// simulate a_j = z_j^N * u_j^-e_j mod N^2
+		pr.E[j], err = randInt(mod)
+		if err != nil {
+			return nil, err
+		}
+		pr.Z[j], err = randUnit(n)
+		if err != nil {
+			return nil, err
+		}
+		ue, err := exp(us[j], new(big.Int).Neg(pr.E[j]), n2)
+		if err != nil {
+			return nil, err
+		}
+		pr.A[j] = mulMod(new(big.Int).Exp(pr.Z[j], n, n2), ue, n2)
+	}
+	// e_i = e - Σ_{j≠i} e_j mod 2^ChallengeBits
+	ei := oneOfChallenge(pub, c, ms, pr.A)
+	for j := range ms {
+		if j != i {
+			ei.Sub(ei, pr.E[j])
+		}
+	}
+	pr.E[i] = ei.Mod(ei, mod)
+	// z_i = ρ * r^e_i mod N
+	pr.Z[i] = mulMod(rho, new(big.Int).Exp(r, pr.E[i], n), n)
+	return pr, nil
+}
+
+// Verify verifies the proof that c encrypts one of the messages ms.
+func (pr *OneOfProof) Verify(pub *pailliar.PublicKey, c *big.Int, ms []*big.Int) error {
+	k := len(ms)
+	if k == 0 || len(pr.A) != k || len(pr.E) != k || len(pr.Z) != k {
+		return fmt.Errorf("illegal proof size")
+	}
+	if err := notNil(append(append(append([]*big.Int{c}, pr.A...), pr.E...), pr.Z...)...); err != nil {
+		return err
+	}
+	if err := checkModulus(pub); err != nil {
+		return err
+	}
+	n := pub.N()
+	n2 := new(big.Int).Mul(n, n)
+	if !isUnit(c, n2) {
+		return fmt.Errorf("c is not a unit")
+	}
+	us, err := oneOfStatements(pub, c, ms)
+	if err != nil {
+		return err
+	}
+	mod := new(big.Int).Lsh(ONE, ChallengeBits)
+	sum := new(big.Int)
+	for j := range ms {
+		if !isUnit(pr.A[j], n2) || !isUnit(pr.Z[j], n) || pr.E[j].Sign() < 0 || pr.E[j].Cmp(mod) >= 0 {
+			return fmt.Errorf("%d : a_j, e_j or z_j is out of range", j)
+		}
+		// z_j^N = a_j * u_j^e_j mod N^2
+		if new(big.Int).Exp(pr.Z[j], n, n2).Cmp(mulMod(pr.A[j], new(big.Int).Exp(us[j], pr.E[j], n2), n2)) != 0 {
+			return fmt.Errorf("%d : z_j^N != a_j * u_j^e_j mod N^2", j)
+		}
+		sum.Add(sum, pr.E[j])
+	}
+	// Σ e_j = e mod 2^ChallengeBits
+	if sum.Mod(sum, mod).Cmp(oneOfChallenge(pub, c, ms, pr.A)) != 0 {
+		return fmt.Errorf("Σ e_j != e")
+	}
+	return nil
+}
+
+// MarshalBinary returns the ASN.1 DER encoding of the proof.
+func (pr *OneOfProof) MarshalBinary() ([]byte, error) {
+	return asn1.Marshal(*pr)
+}
+
+// UnmarshalBinary sets the proof from the ASN.1 DER encoding.
+func (pr *OneOfProof) UnmarshalBinary(data []byte) error {
+	return unmarshalDER(data, pr)
+}
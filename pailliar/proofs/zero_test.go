@@ -0,0 +1,129 @@
+package proofs_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/tnakagawa/goref/pailliar"
+	"github.com/tnakagawa/goref/pailliar/proofs"
+)
+
+func TestZeroProof(t *testing.T) {
+	pub, _ := testKey(t)
+	c, r, err := proofs.Encrypt(pub, big.NewInt(0))
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	pr, err := proofs.ProveZero(pub, c, r)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	err = pr.Verify(pub, c)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	roundTrip(t, pr, &proofs.ZeroProof{}, &proofs.ZeroProof{}, func(p proof) error {
+		return p.(*proofs.ZeroProof).Verify(pub, c)
+	})
+	// the other ciphertext
+	c1, r1, err := proofs.Encrypt(pub, big.NewInt(1))
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	if pr.Verify(pub, c1) == nil {
+		t.Errorf("other ciphertext : Verify must fail")
+	}
+	_, err = proofs.ProveZero(pub, c1, r1)
+	if err == nil {
+		t.Errorf("non-zero : ProveZero must fail")
+	}
+	bad := *pr
+	bad.Z = new(big.Int).Add(pr.Z, big.NewInt(1))
+	if bad.Verify(pub, c) == nil {
+		t.Errorf("tampered z : Verify must fail")
+	}
+}
+
+func TestOneOfProof(t *testing.T) {
+	pub, _ := testKey(t)
+	ms := []*big.Int{big.NewInt(0), big.NewInt(1), big.NewInt(10), big.NewInt(100)}
+	for i, m := range ms {
+		c, r, err := proofs.Encrypt(pub, m)
+		if err != nil {
+			t.Fatalf("error %v", err)
+		}
+		pr, err := proofs.ProveOneOf(pub, c, ms, i, r)
+		if err != nil {
+			t.Fatalf("%d : error %v", i, err)
+		}
+		err = pr.Verify(pub, c, ms)
+		if err != nil {
+			t.Fatalf("%d : error %v", i, err)
+		}
+		roundTrip(t, pr, &proofs.OneOfProof{}, &proofs.OneOfProof{}, func(p proof) error {
+			return p.(*proofs.OneOfProof).Verify(pub, c, ms)
+		})
+		// the wrong index
+		_, err = proofs.ProveOneOf(pub, c, ms, (i+1)%len(ms), r)
+		if err == nil {
+			t.Errorf("%d : wrong index : ProveOneOf must fail", i)
+		}
+		// the other messages
+		if pr.Verify(pub, c, []*big.Int{big.NewInt(2), big.NewInt(3), big.NewInt(4), big.NewInt(5)}) == nil {
+			t.Errorf("%d : other messages : Verify must fail", i)
+		}
+		bad := *pr
+		bad.E = append([]*big.Int{new(big.Int).Add(pr.E[0], big.NewInt(1))}, pr.E[1:]...)
+		if bad.Verify(pub, c, ms) == nil {
+			t.Errorf("%d : tampered e : Verify must fail", i)
+		}
+	}
+	// the statement of zero does not depend on g, but the challenge does
+	zero := []*big.Int{big.NewInt(0)}
+	c, r, err := proofs.Encrypt(pub, zero[0])
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	pr, err := proofs.ProveOneOf(pub, c, zero, 0, r)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	n := pub.N()
+	other, err := pailliar.NewPublicKey(n, new(big.Int).Add(pub.G(), n))
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	if pr.Verify(other, c, zero) == nil {
+		t.Errorf("other g : Verify must fail")
+	}
+}
+
+func TestShortModulus(t *testing.T) {
+	// the proofs for the key of 512 bits, whose factors are as short as the challenges
+	pub, _, err := pailliar.KeyGeneration(512)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	if pub.N().BitLen() >= proofs.MinModulusBits {
+		t.Fatalf("N is not short : %d bits", pub.N().BitLen())
+	}
+	ms := []*big.Int{big.NewInt(0), big.NewInt(1)}
+	c, r, err := proofs.Encrypt(pub, big.NewInt(0))
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	zpr, err := proofs.ProveZero(pub, c, r)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	if zpr.Verify(pub, c) == nil {
+		t.Errorf("short N : ZeroProof.Verify must fail")
+	}
+	opr, err := proofs.ProveOneOf(pub, c, ms, 0, r)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	if opr.Verify(pub, c, ms) == nil {
+		t.Errorf("short N : OneOfProof.Verify must fail")
+	}
+}
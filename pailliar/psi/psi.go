@@ -0,0 +1,251 @@
+// Package psi is the two-party private set intersection cardinality protocol by pailliar cipher.
+//
+// Efficient Private Matching and Set Intersection
+// (Freedman, Nissim, Pinkas, https://doi.org/10.1007/978-3-540-24676-3_1)
+//
+//	Client with the set X = {x_1, ..., x_k}:
+//	  P(z) = Π (z - x_i) = Σ a_j z^j mod n
+//	  sends the public key and Enc(a_0), ..., Enc(a_k)
+//	Server with the set Y:
+//	  for each y in Y : Enc(r_y * P(y)) = (Π Enc(a_j)^(y^j))^r_y with a random r_y
+//	  sends the ciphertexts in a random order
+//	Client:
+//	  |X ∩ Y| is the number of the ciphertexts which decrypt to zero
+//
+// The elements are mapped into Z_n by SHA256.
+// The protocol is secure against the semi-honest parties,
+// the server learns only |X| and the client learns only |X ∩ Y| and |Y|.
+package psi
+
+import (
+	"crypto/rand"
+	"encoding/asn1"
+	"fmt"
+	"math/big"
+
+	"github.com/tnakagawa/goref/pailliar"
+	"github.com/tnakagawa/goref/sha256"
+)
+
+// Request is the message from the client to the server.
+type Request struct {
+	PublicKey    *pailliar.PublicKey // the public key of the client
+	Coefficients []*big.Int          // Enc(a_0), ..., Enc(a_k)
+}
+
+// Response is the message from the server to the client.
+type Response struct {
+	Ciphertexts []*big.Int // Enc(r_y * P(y)) for y in Y in a random order
+}
+
+// The ASN.1 structures of the messages.
+//
+// Request ::= SEQUENCE {
+//     n            INTEGER,            -- the modulus
+//     g            INTEGER,            -- the base
+//     coefficients SEQUENCE OF INTEGER -- Enc(a_0), ..., Enc(a_k)
+// }
+//
+// Response ::= SEQUENCE {
+//     ciphertexts SEQUENCE OF INTEGER
+// }
+
+type requestASN1 struct {
+	N            *big.Int
+	G            *big.Int
+	Coefficients []*big.Int
+}
+
+type responseASN1 struct {
+	Ciphertexts []*big.Int
+}
+
+// element returns the element of Z_n of the identifier.
+func element(n *big.Int, id []byte) *big.Int {
+	x := new(big.Int).SetBytes(sha256.Digest(id))
+	return x.Mod(x, n)
+}
+
+// elements returns the distinct elements of the identifiers.
+func elements(n *big.Int, set [][]byte) []*big.Int {
+	xs := []*big.Int{}
+	seen := map[string]bool{}
+	for _, id := range set {
+		if seen[string(id)] {
+			continue
+		}
+		seen[string(id)] = true
+		xs = append(xs, element(n, id))
+	}
+	return xs
+}
+
+// Client is the client of the protocol.
+type Client struct {
+	pri *pailliar.PrivateKey
+	set [][]byte
+}
+
+// NewClient returns the client with the private key and the set of the identifiers.
+func NewClient(pri *pailliar.PrivateKey, set [][]byte) *Client {
+	return &Client{pri: pri, set: set}
+}
+
+// Request returns the request of the encrypted coefficients of P(z) = Π (z - x_i) mod n.
+func (c *Client) Request() (*Request, error) {
+	pub := c.pri.PublicKey()
+	n := pub.N()
+	xs := elements(n, c.set)
+	if len(xs) == 0 {
+		return nil, fmt.Errorf("the set is empty")
+	}
+	// as[j] is the coefficient of z^j
+	as := []*big.Int{big.NewInt(1)}
+	for _, x := range xs {
+		// (Σ a_j z^j) * (z - x)
+		bs := make([]*big.Int, len(as)+1)
+		bs[len(as)] = new(big.Int).Set(as[len(as)-1])
+		for j := len(as) - 1; j >= 1; j-- {
+			bs[j] = new(big.Int).Sub(as[j-1], new(big.Int).Mul(as[j], x))
+			bs[j].Mod(bs[j], n)
+		}
+		bs[0] = new(big.Int).Neg(new(big.Int).Mul(as[0], x))
+		bs[0].Mod(bs[0], n)
+		as = bs
+	}
+	req := &Request{PublicKey: pub}
+	for _, a := range as {
+		ca, err := pub.Encryption(a)
+		if err != nil {
+			return nil, err
+		}
+		req.Coefficients = append(req.Coefficients, ca)
+	}
+	return req, nil
+}
+
+// Cardinality returns |X ∩ Y| from the response of the server.
+func (c *Client) Cardinality(res *Response) (int, error) {
+	count := 0
+	for i, ct := range res.Ciphertexts {
+		if ct == nil {
+			return 0, fmt.Errorf("%d : missing ciphertext", i)
+		}
+		m, err := c.pri.Decryption(ct)
+		if err != nil {
+			return 0, fmt.Errorf("%d : %v", i, err)
+		}
+		if m.Sign() == 0 {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// Respond returns the response of the server with the set of the identifiers.
+func Respond(req *Request, set [][]byte) (*Response, error) {
+	err := req.validate()
+	if err != nil {
+		return nil, err
+	}
+	pub := req.PublicKey
+	n := pub.N()
+	ys := elements(n, set)
+	res := &Response{}
+	for _, y := range ys {
+		// Enc(P(y)) by Horner's method
+		acc := req.Coefficients[len(req.Coefficients)-1]
+		for j := len(req.Coefficients) - 2; j >= 0; j-- {
+			acc = pub.Mul(pub.Exp(acc, y), req.Coefficients[j])
+		}
+		// Enc(r * P(y)) with a random r in [1, n)
+		r, err := rand.Int(rand.Reader, new(big.Int).Sub(n, big.NewInt(1)))
+		if err != nil {
+			return nil, err
+		}
+		acc = pub.Exp(acc, r.Add(r, big.NewInt(1)))
+		// rerandomize
+		acc = pub.Mul(acc, pub.NewRn())
+		res.Ciphertexts = append(res.Ciphertexts, acc)
+	}
+	// shuffle by Fisher–Yates
+	for i := len(res.Ciphertexts) - 1; i > 0; i-- {
+		j, err := rand.Int(rand.Reader, big.NewInt(int64(i+1)))
+		if err != nil {
+			return nil, err
+		}
+		k := int(j.Int64())
+		res.Ciphertexts[i], res.Ciphertexts[k] = res.Ciphertexts[k], res.Ciphertexts[i]
+	}
+	return res, nil
+}
+
+// validate checks the request.
+func (req *Request) validate() error {
+	if req.PublicKey == nil {
+		return fmt.Errorf("missing public key")
+	}
+	err := req.PublicKey.Validate()
+	if err != nil {
+		return err
+	}
+	if len(req.Coefficients) < 2 {
+		return fmt.Errorf("the polynomial is constant")
+	}
+	n := req.PublicKey.N()
+	n2 := new(big.Int).Mul(n, n)
+	for i, c := range req.Coefficients {
+		// c in Z*_{n^2}
+		if c == nil || c.Sign() <= 0 || c.Cmp(n2) >= 0 || pailliar.GCD(c, n).Cmp(big.NewInt(1)) != 0 {
+			return fmt.Errorf("%d : the coefficient is not in Z*_{n^2}", i)
+		}
+	}
+	return nil
+}
+
+// MarshalBinary returns the ASN.1 DER encoding of the request.
+func (req *Request) MarshalBinary() ([]byte, error) {
+	return asn1.Marshal(requestASN1{N: req.PublicKey.N(), G: req.PublicKey.G(), Coefficients: req.Coefficients})
+}
+
+// UnmarshalBinary sets the request from the ASN.1 DER encoding and validates it.
+func (req *Request) UnmarshalBinary(data []byte) error {
+	r := &requestASN1{}
+	rest, err := asn1.Unmarshal(data, r)
+	if err != nil {
+		return err
+	}
+	if len(rest) != 0 {
+		return fmt.Errorf("trailing data")
+	}
+	pub, err := pailliar.NewPublicKey(r.N, r.G)
+	if err != nil {
+		return err
+	}
+	tmp := &Request{PublicKey: pub, Coefficients: r.Coefficients}
+	err = tmp.validate()
+	if err != nil {
+		return err
+	}
+	*req = *tmp
+	return nil
+}
+
+// MarshalBinary returns the ASN.1 DER encoding of the response.
+func (res *Response) MarshalBinary() ([]byte, error) {
+	return asn1.Marshal(responseASN1{Ciphertexts: res.Ciphertexts})
+}
+
+// UnmarshalBinary sets the response from the ASN.1 DER encoding.
+func (res *Response) UnmarshalBinary(data []byte) error {
+	r := &responseASN1{}
+	rest, err := asn1.Unmarshal(data, r)
+	if err != nil {
+		return err
+	}
+	if len(rest) != 0 {
+		return fmt.Errorf("trailing data")
+	}
+	res.Ciphertexts = r.Ciphertexts
+	return nil
+}
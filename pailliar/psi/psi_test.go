@@ -0,0 +1,144 @@
+package psi_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/tnakagawa/goref/pailliar"
+	"github.com/tnakagawa/goref/pailliar/psi"
+)
+
+// ids returns the identifiers with the prefix in [from, to).
+func ids(prefix string, from, to int) [][]byte {
+	set := [][]byte{}
+	for i := from; i < to; i++ {
+		set = append(set, []byte(fmt.Sprintf("%s%04d", prefix, i)))
+	}
+	return set
+}
+
+// drive runs the protocol in process, the messages are serialized by the encoding.
+func drive(t *testing.T, pri *pailliar.PrivateKey, x, y [][]byte, encoding string) int {
+	client := psi.NewClient(pri, x)
+	req, err := client.Request()
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	// client -> server
+	req2 := &psi.Request{}
+	res2 := &psi.Response{}
+	switch encoding {
+	case "DER":
+		bs, err := req.MarshalBinary()
+		if err != nil {
+			t.Fatalf("error %v", err)
+		}
+		err = req2.UnmarshalBinary(bs)
+		if err != nil {
+			t.Fatalf("error %v", err)
+		}
+	case "JSON":
+		bs, err := json.Marshal(req)
+		if err != nil {
+			t.Fatalf("error %v", err)
+		}
+		err = json.Unmarshal(bs, req2)
+		if err != nil {
+			t.Fatalf("error %v", err)
+		}
+	}
+	res, err := psi.Respond(req2, y)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	// server -> client
+	switch encoding {
+	case "DER":
+		bs, err := res.MarshalBinary()
+		if err != nil {
+			t.Fatalf("error %v", err)
+		}
+		err = res2.UnmarshalBinary(bs)
+		if err != nil {
+			t.Fatalf("error %v", err)
+		}
+	case "JSON":
+		bs, err := json.Marshal(res)
+		if err != nil {
+			t.Fatalf("error %v", err)
+		}
+		err = json.Unmarshal(bs, res2)
+		if err != nil {
+			t.Fatalf("error %v", err)
+		}
+	}
+	if len(res2.Ciphertexts) != len(res.Ciphertexts) {
+		t.Fatalf("the response size : %d != %d", len(res2.Ciphertexts), len(res.Ciphertexts))
+	}
+	count, err := client.Cardinality(res2)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	return count
+}
+
+func TestCardinality(t *testing.T) {
+	_, pri, err := pailliar.KeyGeneration(512)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	tests := []struct {
+		name string
+		x, y [][]byte
+		want int
+	}{
+		{"overlap", ids("user", 0, 10), ids("user", 7, 20), 3},
+		{"disjoint", ids("user", 0, 5), ids("user", 5, 10), 0},
+		{"subset", ids("user", 0, 4), ids("user", 0, 12), 4},
+		{"duplicated", append(ids("a", 0, 3), ids("a", 0, 3)...), append(ids("a", 2, 5), ids("a", 2, 5)...), 1},
+		{"single", ids("x", 0, 1), ids("x", 0, 1), 1},
+	}
+	for _, test := range tests {
+		for _, encoding := range []string{"DER", "JSON"} {
+			count := drive(t, pri, test.x, test.y, encoding)
+			if count != test.want {
+				t.Errorf("%s %s : %d != %d", test.name, encoding, count, test.want)
+			}
+		}
+	}
+}
+
+func TestInvalidMessages(t *testing.T) {
+	pub, pri, err := pailliar.KeyGeneration(512)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	_, err = psi.NewClient(pri, nil).Request()
+	if err == nil {
+		t.Errorf("empty set : Request must fail")
+	}
+	req, err := psi.NewClient(pri, ids("user", 0, 3)).Request()
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	bad := &psi.Request{PublicKey: pub, Coefficients: req.Coefficients[:1]}
+	_, err = psi.Respond(bad, ids("user", 0, 3))
+	if err == nil {
+		t.Errorf("constant : Respond must fail")
+	}
+	bad = &psi.Request{PublicKey: pub, Coefficients: append([]*big.Int{pub.N()}, req.Coefficients[1:]...)}
+	_, err = psi.Respond(bad, ids("user", 0, 3))
+	if err == nil {
+		t.Errorf("c = n : Respond must fail")
+	}
+	bs, err := req.MarshalBinary()
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	err = (&psi.Request{}).UnmarshalBinary(append(bs, 0x00))
+	if err == nil {
+		t.Errorf("trailing data : UnmarshalBinary must fail")
+	}
+}
@@ -0,0 +1,269 @@
+// Package threshold is the threshold decryption of pailliar cipher.
+// A trusted dealer shares the decryption exponent by Shamir's secret sharing,
+// so that any t of the l parties can decrypt together, but no t-1 parties can.
+//
+// A Generalisation, a Simplification and Some Applications of Paillier's Probabilistic Public-Key System
+// (Damgård, Jurik, https://doi.org/10.1007/3-540-44586-2_9) Section 4, with s = 1.
+//
+//	Key generation:
+//	  p = 2p' + 1, q = 2q' + 1 are safe primes, N = pq, m = p'q', g = N + 1
+//	  d = 0 mod m, d = 1 mod N
+//	  f(X) = d + a_1 X + ... + a_t-1 X^t-1 mod Nm, s_i = f(i) for i = 1..l
+//	  v is a random square in Z*_N^2, v_i = v^(Δ s_i) mod N^2, where Δ = l!
+//	Decryption:
+//	  c_i = c^(2Δ s_i) mod N^2 with the proof that log_c^4(c_i^2) = log_v(v_i)
+//	  c' = Π_{i∈S} c_i^(2λ_0,i) = (1 + N)^(4Δ^2 M) mod N^2, λ_0,i = Δ Π_{i'∈S\{i}} -i' / (i - i')
+//	  M = L(c') * (4Δ^2)^-1 mod N
+package threshold
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+
+	"github.com/tnakagawa/goref/pailliar"
+	"github.com/tnakagawa/goref/sha256"
+)
+
+// ONE is 1 of *big.Int .
+var ONE = big.NewInt(1)
+
+// ChallengeBits is the bits of the challenge of the proof of the partial decryption.
+const ChallengeBits = 256
+
+// PublicKey is the threshold public key.
+type PublicKey struct {
+	Pub *pailliar.PublicKey // the public key with g = N + 1
+	T   int                 // the threshold t
+	L   int                 // the number of the parties l
+	V   *big.Int            // v
+	Vs  []*big.Int          // v_i = v^(Δ s_i) mod N^2 , the verification key of the i-th party is Vs[i-1]
+}
+
+// KeyShare is the secret share of the i-th party.
+type KeyShare struct {
+	Index int      // i in [1, l]
+	S     *big.Int // s_i = f(i) mod Nm
+}
+
+// PartialDecryption is the partial decryption of the i-th party with the proof of the correctness.
+type PartialDecryption struct {
+	Index int      // i
+	C     *big.Int // c_i = c^(2Δ s_i) mod N^2
+	E     *big.Int // the challenge e
+	Z     *big.Int // z = r + e Δ s_i
+}
+
+// delta returns Δ = l!.
+func delta(l int) *big.Int {
+	return new(big.Int).MulRange(1, int64(l))
+}
+
+// Deal returns the threshold public key of the bits and the key shares of the l parties with the threshold t.
+func Deal(bits, t, l int) (*PublicKey, []*KeyShare, error) {
+	return DealContext(context.Background(), bits, t, l)
+}
+
+// DealContext returns the threshold public key and the key shares,
+// or the error of ctx if it is done before the safe primes are found.
+func DealContext(ctx context.Context, bits, t, l int) (*PublicKey, []*KeyShare, error) {
+	if t < 1 || t > l {
+		return nil, nil, fmt.Errorf("illegal threshold %d of %d", t, l)
+	}
+	// p = 2p' + 1, q = 2q' + 1
+	p, err := pailliar.GeneratePrime(ctx, bits/2, true)
+	if err != nil {
+		return nil, nil, err
+	}
+	var q *big.Int
+	for q == nil || q.Cmp(p) == 0 {
+		q, err = pailliar.GeneratePrime(ctx, bits-bits/2, true)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return deal(p, q, t, l)
+}
+
+// deal returns the threshold public key and the key shares for the safe primes p and q.
+func deal(p, q *big.Int, t, l int) (*PublicKey, []*KeyShare, error) {
+	n := new(big.Int).Mul(p, q)
+	n2 := new(big.Int).Mul(n, n)
+	pub, err := pailliar.NewPublicKey(n, new(big.Int).Add(n, ONE))
+	if err != nil {
+		return nil, nil, err
+	}
+	// m = p'q'
+	m := new(big.Int).Mul(new(big.Int).Rsh(p, 1), new(big.Int).Rsh(q, 1))
+	// d = 0 mod m, d = 1 mod N : d = m * (m^-1 mod N)
+	mInv := new(big.Int).ModInverse(m, n)
+	if mInv == nil {
+		return nil, nil, fmt.Errorf("gcd(m, N) != 1")
+	}
+	d := new(big.Int).Mul(m, mInv)
+	// f(X) = d + a_1 X + ... + a_t-1 X^t-1 mod Nm
+	nm := new(big.Int).Mul(n, m)
+	as := []*big.Int{d}
+	for i := 1; i < t; i++ {
+		a, err := rand.Int(rand.Reader, nm)
+		if err != nil {
+			return nil, nil, err
+		}
+		as = append(as, a)
+	}
+	// v is a random square in Z*_N^2
+	var v *big.Int
+	for {
+		r, err := rand.Int(rand.Reader, n2)
+		if err != nil {
+			return nil, nil, err
+		}
+		if r.Sign() > 0 && new(big.Int).GCD(nil, nil, r, n).Cmp(ONE) == 0 {
+			v = new(big.Int).Exp(r, big.NewInt(2), n2)
+			break
+		}
+	}
+	tpub := &PublicKey{Pub: pub, T: t, L: l, V: v}
+	shares := []*KeyShare{}
+	dl := delta(l)
+	for i := 1; i <= l; i++ {
+		// s_i = f(i) mod Nm by Horner's method
+		x := big.NewInt(int64(i))
+		s := new(big.Int)
+		for j := len(as) - 1; j >= 0; j-- {
+			s.Mul(s, x).Add(s, as[j]).Mod(s, nm)
+		}
+		shares = append(shares, &KeyShare{Index: i, S: s})
+		// v_i = v^(Δ s_i) mod N^2
+		tpub.Vs = append(tpub.Vs, new(big.Int).Exp(v, new(big.Int).Mul(dl, s), n2))
+	}
+	return tpub, shares, nil
+}
+
+// challenge returns the challenge e = H(N, c^4, c_i^2, v, v_i, a, b) in [0, 2^ChallengeBits).
+func challenge(xs ...*big.Int) *big.Int {
+	data := []byte("goref/pailliar/threshold")
+	for _, x := range xs {
+		l := make([]byte, 8)
+		binary.BigEndian.PutUint64(l, uint64(len(x.Bytes())))
+		data = append(append(data, l...), x.Bytes()...)
+	}
+	return new(big.Int).SetBytes(sha256.Digest(data))
+}
+
+// Decrypt returns the partial decryption c_i = c^(2Δ s_i) mod N^2 of the ciphertext c with the proof.
+func (ks *KeyShare) Decrypt(tpub *PublicKey, c *big.Int) (*PartialDecryption, error) {
+	if ks.Index < 1 || ks.Index > tpub.L || len(tpub.Vs) != tpub.L {
+		return nil, fmt.Errorf("illegal index %d", ks.Index)
+	}
+	pub := tpub.Pub
+	n := pub.N()
+	n2 := new(big.Int).Mul(n, n)
+	if c.Sign() <= 0 || c.Cmp(n2) >= 0 || new(big.Int).GCD(nil, nil, c, n).Cmp(ONE) != 0 {
+		return nil, fmt.Errorf("c is not in Z*_N^2")
+	}
+	// Δ s_i
+	ds := new(big.Int).Mul(delta(tpub.L), ks.S)
+	ci := pub.Exp(c, new(big.Int).Lsh(ds, 1))
+	// the proof that log_c^4(c_i^2) = log_v(v_i) = Δ s_i
+	// r is random of |N^2| + 2 * ChallengeBits bits
+	r, err := rand.Int(rand.Reader, new(big.Int).Lsh(ONE, uint(n2.BitLen()+2*ChallengeBits)))
+	if err != nil {
+		return nil, err
+	}
+	c4 := pub.Exp(c, big.NewInt(4))
+	a := pub.Exp(c4, r)
+	b := pub.Exp(tpub.V, r)
+	e := challenge(n, c4, pub.Mul(ci, ci), tpub.V, tpub.Vs[ks.Index-1], a, b)
+	z := new(big.Int).Add(r, new(big.Int).Mul(e, ds))
+	return &PartialDecryption{Index: ks.Index, C: ci, E: e, Z: z}, nil
+}
+
+// Verify verifies the proof of the partial decryption of the ciphertext c.
+func (pd *PartialDecryption) Verify(tpub *PublicKey, c *big.Int) error {
+	if pd.Index < 1 || pd.Index > tpub.L || len(tpub.Vs) != tpub.L {
+		return fmt.Errorf("illegal index %d", pd.Index)
+	}
+	if pd.C == nil || pd.E == nil || pd.Z == nil || pd.Z.Sign() < 0 {
+		return fmt.Errorf("missing parameters")
+	}
+	pub := tpub.Pub
+	n := pub.N()
+	n2 := new(big.Int).Mul(n, n)
+	for _, x := range []*big.Int{c, pd.C} {
+		if x.Sign() <= 0 || x.Cmp(n2) >= 0 || new(big.Int).GCD(nil, nil, x, n).Cmp(ONE) != 0 {
+			return fmt.Errorf("the ciphertext is not in Z*_N^2")
+		}
+	}
+	vi := tpub.Vs[pd.Index-1]
+	c4 := pub.Exp(c, big.NewInt(4))
+	ci2 := pub.Mul(pd.C, pd.C)
+	// a = c^(4z) * c_i^(-2e), b = v^z * v_i^(-e)
+	ne := new(big.Int).Neg(pd.E)
+	a := pub.Mul(pub.Exp(c4, pd.Z), pub.Exp(ci2, ne))
+	vie := pub.Exp(vi, ne)
+	if vie == nil {
+		return fmt.Errorf("v_i is not invertible")
+	}
+	b := pub.Mul(pub.Exp(tpub.V, pd.Z), vie)
+	if challenge(n, c4, ci2, tpub.V, vi, a, b).Cmp(pd.E) != 0 {
+		return fmt.Errorf("%d : log_c^4(c_i^2) != log_v(v_i)", pd.Index)
+	}
+	return nil
+}
+
+// Combine returns the plaintext of the ciphertext c from the partial decryptions,
+// the first t valid partial decryptions of the distinct parties are used.
+func (tpub *PublicKey) Combine(c *big.Int, pds []*PartialDecryption) (*big.Int, error) {
+	pub := tpub.Pub
+	n := pub.N()
+	// S is the set of the first t valid partial decryptions.
+	S := []*PartialDecryption{}
+	used := map[int]bool{}
+	for _, pd := range pds {
+		if len(S) == tpub.T {
+			break
+		}
+		if used[pd.Index] || pd.Verify(tpub, c) != nil {
+			continue
+		}
+		used[pd.Index] = true
+		S = append(S, pd)
+	}
+	if len(S) < tpub.T {
+		return nil, fmt.Errorf("not enough valid partial decryptions %d < %d", len(S), tpub.T)
+	}
+	dl := delta(tpub.L)
+	// c' = Π c_i^(2λ_0,i) mod N^2
+	cd := big.NewInt(1)
+	for _, pd := range S {
+		// λ_0,i = Δ Π_{i'∈S\{i}} -i' / (i - i') is an integer
+		num := new(big.Int).Set(dl)
+		den := big.NewInt(1)
+		for _, pd2 := range S {
+			if pd2.Index == pd.Index {
+				continue
+			}
+			num.Mul(num, big.NewInt(int64(-pd2.Index)))
+			den.Mul(den, big.NewInt(int64(pd.Index-pd2.Index)))
+		}
+		lam := num.Quo(num, den)
+		x := pub.Exp(pd.C, lam.Lsh(lam, 1))
+		if x == nil {
+			return nil, fmt.Errorf("c_i is not invertible")
+		}
+		cd = pub.Mul(cd, x)
+	}
+	// M = L(c') * (4Δ^2)^-1 mod N
+	l := pailliar.L(cd, n)
+	if l == nil {
+		return nil, fmt.Errorf("c' != 1 mod N")
+	}
+	inv := new(big.Int).ModInverse(new(big.Int).Lsh(new(big.Int).Mul(dl, dl), 2), n)
+	if inv == nil {
+		return nil, fmt.Errorf("4Δ^2 is not invertible")
+	}
+	return l.Mul(l, inv).Mod(l, n), nil
+}
@@ -0,0 +1,119 @@
+package threshold_test
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/tnakagawa/goref/pailliar/threshold"
+)
+
+func TestThreshold(t *testing.T) {
+	const T, L = 3, 5
+	tpub, shares, err := threshold.Deal(512, T, L)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	pub := tpub.Pub
+	// each party encrypts the metric and the ciphertexts are aggregated
+	sum := big.NewInt(0)
+	var c *big.Int
+	for i := 1; i <= L; i++ {
+		m := big.NewInt(int64(i * 1000))
+		sum.Add(sum, m)
+		ci, err := pub.Encryption(m)
+		if err != nil {
+			t.Fatalf("error %v", err)
+		}
+		if c == nil {
+			c = ci
+		} else {
+			c = pub.Mul(c, ci)
+		}
+	}
+	pds := []*threshold.PartialDecryption{}
+	for _, ks := range shares {
+		pd, err := ks.Decrypt(tpub, c)
+		if err != nil {
+			t.Fatalf("error %v", err)
+		}
+		err = pd.Verify(tpub, c)
+		if err != nil {
+			t.Fatalf("%d : error %v", pd.Index, err)
+		}
+		pds = append(pds, pd)
+	}
+	// any t shares decrypt
+	subsets := [][]int{{0, 1, 2}, {2, 3, 4}, {4, 0, 2}, {1, 3, 4}}
+	for _, subset := range subsets {
+		sel := []*threshold.PartialDecryption{}
+		for _, i := range subset {
+			sel = append(sel, pds[i])
+		}
+		m, err := tpub.Combine(c, sel)
+		if err != nil {
+			t.Fatalf("%v : error %v", subset, err)
+		}
+		if m.Cmp(sum) != 0 {
+			t.Errorf("%v : m != sum : %v != %v", subset, m, sum)
+		}
+	}
+	// t-1 shares do not decrypt
+	_, err = tpub.Combine(c, pds[:T-1])
+	if err == nil {
+		t.Errorf("t-1 shares : Combine must fail")
+	}
+	// the duplicated shares are counted once
+	_, err = tpub.Combine(c, []*threshold.PartialDecryption{pds[0], pds[0], pds[1]})
+	if err == nil {
+		t.Errorf("duplicated shares : Combine must fail")
+	}
+	// the invalid partial decryption is rejected and skipped
+	bad := *pds[0]
+	bad.C = pub.Mul(bad.C, bad.C)
+	if bad.Verify(tpub, c) == nil {
+		t.Errorf("tampered c_i : Verify must fail")
+	}
+	bad2 := *pds[1]
+	bad2.Index = 3
+	if bad2.Verify(tpub, c) == nil {
+		t.Errorf("other index : Verify must fail")
+	}
+	// the public key without the verification keys
+	short := *tpub
+	short.Vs = short.Vs[:L-1]
+	_, err = shares[L-1].Decrypt(&short, c)
+	if err == nil {
+		t.Errorf("missing v_i : Decrypt must fail")
+	}
+	m, err := tpub.Combine(c, append([]*threshold.PartialDecryption{&bad, &bad2}, pds[2:]...))
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	if m.Cmp(sum) != 0 {
+		t.Errorf("m != sum : %v != %v", m, sum)
+	}
+	// JSON
+	bs, err := json.Marshal(tpub)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	tpub2 := &threshold.PublicKey{}
+	err = json.Unmarshal(bs, tpub2)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	bs, err = json.Marshal(pds[3])
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	pd := &threshold.PartialDecryption{}
+	err = json.Unmarshal(bs, pd)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	err = pd.Verify(tpub2, c)
+	if err != nil {
+		t.Errorf("JSON : error %v", err)
+	}
+}
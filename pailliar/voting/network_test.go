@@ -0,0 +1,54 @@
+package voting_test
+
+import (
+	"encoding/json"
+	"math/rand"
+)
+
+// network is the deterministic simulated network.
+// The messages are serialized by JSON, some of them are duplicated,
+// and they are delivered in the order shuffled by the seed.
+type network struct {
+	rnd   *rand.Rand
+	dup   float64  // the probability of the duplication
+	queue [][]byte // the messages in flight
+}
+
+// newNetwork returns the simulated network of the seed.
+func newNetwork(seed int64, dup float64) *network {
+	return &network{rnd: rand.New(rand.NewSource(seed)), dup: dup}
+}
+
+// send sends the message.
+func (nw *network) send(v interface{}) error {
+	bs, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	nw.queue = append(nw.queue, bs)
+	if nw.rnd.Float64() < nw.dup {
+		nw.queue = append(nw.queue, bs)
+	}
+	return nil
+}
+
+// deliver delivers the messages in flight in the shuffled order.
+func (nw *network) deliver(f func([]byte)) {
+	queue := nw.queue
+	nw.queue = nil
+	nw.rnd.Shuffle(len(queue), func(i, j int) {
+		queue[i], queue[j] = queue[j], queue[i]
+	})
+	for _, bs := range queue {
+		f(bs)
+	}
+}
+
+// broadcast returns the copy of v through the network.
+func broadcast(v interface{}, out interface{}) error {
+	bs, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(bs, out)
+}
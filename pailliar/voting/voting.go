@@ -0,0 +1,221 @@
+// Package voting is the private secure-sum and voting protocol by pailliar cipher.
+//
+// The tallier publishes the pailliar public key with the Paillier-Blum modulus proof.
+// Each voter submits the encrypted ballot with the proof that it encrypts a valid vote.
+// The tallier verifies the ballots, sums them homomorphically by PublicKey.Mul
+// and decrypts only the final tally, so that no single ballot is decrypted.
+//
+// With K = 1, a ballot encrypts 0 or 1 and the tally is the secure sum of the bits.
+// With K ≥ 2, a ballot for the j-th option encrypts B^j, where B = MaxVoters + 1,
+// and the tally Σ count_j B^j is decoded digit by digit in base B.
+//
+// The tallier is trusted to decrypt only the tally, and the ciphertexts are not bound to the voters,
+// so that the tallier rejects the duplicated ciphertexts as the replayed ballots.
+package voting
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/tnakagawa/goref/pailliar"
+	"github.com/tnakagawa/goref/pailliar/proofs"
+)
+
+// Params is the public parameters of the election published by the tallier.
+type Params struct {
+	ID        string              // the election identifier
+	PublicKey *pailliar.PublicKey // the public key of the tallier
+	ModProof  *proofs.ModProof    // the proof that the modulus is a Paillier-Blum modulus
+	K         int                 // the number of the options
+	MaxVoters int                 // the maximum number of the voters
+}
+
+// Ballot is the encrypted vote with the proof of the validity.
+type Ballot struct {
+	ID    string            // the election identifier
+	Voter string            // the voter identifier
+	C     *big.Int          // the encrypted vote
+	Proof proofs.OneOfProof // the proof that C encrypts one of the valid votes
+}
+
+// Result is the decrypted tally.
+type Result struct {
+	ID     string  // the election identifier
+	Voters int     // the number of the counted ballots
+	Counts []int64 // the count of each option, or the sum of the bits if K = 1
+}
+
+// messages returns the valid votes, {0, 1} if K = 1, or {B^0, ..., B^(K-1)} otherwise.
+func (p *Params) messages() []*big.Int {
+	if p.K == 1 {
+		return []*big.Int{big.NewInt(0), big.NewInt(1)}
+	}
+	b := big.NewInt(int64(p.MaxVoters + 1))
+	ms := []*big.Int{}
+	m := big.NewInt(1)
+	for j := 0; j < p.K; j++ {
+		ms = append(ms, new(big.Int).Set(m))
+		m.Mul(m, b)
+	}
+	return ms
+}
+
+// checkSize returns an error if the tally may overflow the plaintext space.
+func (p *Params) checkSize() error {
+	if p.K < 1 || p.MaxVoters < 1 {
+		return fmt.Errorf("illegal K %d or MaxVoters %d", p.K, p.MaxVoters)
+	}
+	// B^K ≤ n
+	b := big.NewInt(int64(p.MaxVoters + 1))
+	if new(big.Int).Exp(b, big.NewInt(int64(p.K)), nil).Cmp(p.PublicKey.N()) > 0 {
+		return fmt.Errorf("(MaxVoters + 1)^K exceeds n")
+	}
+	return nil
+}
+
+// Verify verifies the public parameters before voting.
+func (p *Params) Verify() error {
+	if p.PublicKey == nil || p.ModProof == nil {
+		return fmt.Errorf("missing parameters")
+	}
+	err := p.PublicKey.Validate()
+	if err != nil {
+		return err
+	}
+	// g = n + 1
+	if p.PublicKey.G().Cmp(new(big.Int).Add(p.PublicKey.N(), big.NewInt(1))) != 0 {
+		return fmt.Errorf("g != n + 1")
+	}
+	// the proofs of the ballots are sound only for N of MinModulusBits or more
+	if p.PublicKey.N().BitLen() < proofs.MinModulusBits {
+		return fmt.Errorf("N is too short : %d < %d bits", p.PublicKey.N().BitLen(), proofs.MinModulusBits)
+	}
+	err = p.checkSize()
+	if err != nil {
+		return err
+	}
+	return p.ModProof.Verify(p.PublicKey)
+}
+
+// Cast returns the ballot of the voter for the choice in [0, K), or the bit in {0, 1} if K = 1.
+// The parameters must be verified by Verify before.
+func Cast(p *Params, voter string, choice int) (*Ballot, error) {
+	ms := p.messages()
+	if choice < 0 || choice >= len(ms) {
+		return nil, fmt.Errorf("illegal choice %d", choice)
+	}
+	c, r, err := proofs.Encrypt(p.PublicKey, ms[choice])
+	if err != nil {
+		return nil, err
+	}
+	pr, err := proofs.ProveOneOf(p.PublicKey, c, ms, choice, r)
+	if err != nil {
+		return nil, err
+	}
+	return &Ballot{ID: p.ID, Voter: voter, C: c, Proof: *pr}, nil
+}
+
+// Verify verifies the ballot for the parameters.
+func (b *Ballot) Verify(p *Params) error {
+	if b.ID != p.ID {
+		return fmt.Errorf("the election is different : %q != %q", b.ID, p.ID)
+	}
+	if b.C == nil {
+		return fmt.Errorf("missing parameters")
+	}
+	return b.Proof.Verify(p.PublicKey, b.C, p.messages())
+}
+
+// Tallier collects the ballots and decrypts the tally.
+type Tallier struct {
+	params *Params
+	pri    *pailliar.PrivateKey
+	sum    *big.Int        // the product of the ciphertexts
+	voters map[string]bool // the voters who have voted
+	seen   map[string]bool // the ciphertexts which have been counted
+	closed bool            // whether the tally is decrypted
+}
+
+// NewTallier returns the tallier of the election with the key of the bits.
+// The primes are safe primes, which are 3 mod 4 for the Paillier-Blum modulus proof.
+func NewTallier(ctx context.Context, id string, bits, k, maxVoters int) (*Tallier, error) {
+	_, pri, err := pailliar.KeyGenerationContext(ctx, bits, true)
+	if err != nil {
+		return nil, err
+	}
+	return NewTallierWithKey(id, pri, k, maxVoters)
+}
+
+// NewTallierWithKey returns the tallier of the election with the private key,
+// whose primes are 3 mod 4 and whose base g is n + 1.
+func NewTallierWithKey(id string, pri *pailliar.PrivateKey, k, maxVoters int) (*Tallier, error) {
+	pr, err := proofs.ProveMod(pri)
+	if err != nil {
+		return nil, err
+	}
+	p := &Params{ID: id, PublicKey: pri.PublicKey(), ModProof: pr, K: k, MaxVoters: maxVoters}
+	err = p.Verify()
+	if err != nil {
+		return nil, err
+	}
+	return &Tallier{params: p, pri: pri, sum: big.NewInt(1), voters: map[string]bool{}, seen: map[string]bool{}}, nil
+}
+
+// Params returns the public parameters of the election.
+func (t *Tallier) Params() *Params {
+	return t.params
+}
+
+// Voters returns the number of the counted ballots.
+func (t *Tallier) Voters() int {
+	return len(t.voters)
+}
+
+// Submit verifies the ballot and adds it to the tally.
+// The ballot is rejected if the voter has voted or the ciphertext has been counted.
+func (t *Tallier) Submit(b *Ballot) error {
+	if t.closed {
+		return fmt.Errorf("the tally is closed")
+	}
+	if t.voters[b.Voter] {
+		return fmt.Errorf("the voter %q has voted", b.Voter)
+	}
+	if len(t.voters) >= t.params.MaxVoters {
+		return fmt.Errorf("too many voters")
+	}
+	err := b.Verify(t.params)
+	if err != nil {
+		return fmt.Errorf("the voter %q : %v", b.Voter, err)
+	}
+	if t.seen[b.C.String()] {
+		return fmt.Errorf("the voter %q : the ballot is replayed", b.Voter)
+	}
+	t.voters[b.Voter] = true
+	t.seen[b.C.String()] = true
+	// c1 * c2 -> m1 + m2
+	t.sum = t.params.PublicKey.Mul(t.sum, b.C)
+	return nil
+}
+
+// Tally closes the election and returns the decrypted tally.
+func (t *Tallier) Tally() (*Result, error) {
+	t.closed = true
+	m, err := t.pri.Decryption(t.sum)
+	if err != nil {
+		return nil, err
+	}
+	res := &Result{ID: t.params.ID, Voters: len(t.voters)}
+	if t.params.K == 1 {
+		res.Counts = []int64{m.Int64()}
+		return res, nil
+	}
+	// the digits in base B
+	b := big.NewInt(int64(t.params.MaxVoters + 1))
+	d := new(big.Int)
+	for j := 0; j < t.params.K; j++ {
+		m.QuoRem(m, b, d)
+		res.Counts = append(res.Counts, d.Int64())
+	}
+	return res, nil
+}
@@ -0,0 +1,171 @@
+package voting_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"math/rand"
+	"testing"
+
+	"github.com/tnakagawa/goref/pailliar"
+	"github.com/tnakagawa/goref/pailliar/voting"
+)
+
+// run runs the election of the voters with the choices over the simulated network,
+// and returns the result and the number of the rejected messages.
+func run(t *testing.T, tallier *voting.Tallier, choices []int, seed int64) (*voting.Result, int) {
+	params := &voting.Params{}
+	err := broadcast(tallier.Params(), params)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	err = params.Verify()
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	nw := newNetwork(seed, 0.3)
+	for i, choice := range choices {
+		b, err := voting.Cast(params, fmt.Sprintf("voter%02d", i), choice)
+		if err != nil {
+			t.Fatalf("error %v", err)
+		}
+		err = nw.send(b)
+		if err != nil {
+			t.Fatalf("error %v", err)
+		}
+	}
+	rejected := 0
+	nw.deliver(func(bs []byte) {
+		b := &voting.Ballot{}
+		err := json.Unmarshal(bs, b)
+		if err != nil {
+			t.Fatalf("error %v", err)
+		}
+		if err := tallier.Submit(b); err != nil {
+			t.Logf("rejected : %v", err)
+			rejected++
+		}
+	})
+	res, err := tallier.Tally()
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	return res, rejected
+}
+
+func TestVoting(t *testing.T) {
+	const K, voters = 3, 15
+	tallier, err := voting.NewTallier(context.Background(), "election-1", 1024, K, 20)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	rnd := rand.New(rand.NewSource(1))
+	choices := []int{}
+	want := make([]int64, K)
+	for i := 0; i < voters; i++ {
+		c := rnd.Intn(K)
+		choices = append(choices, c)
+		want[c]++
+	}
+	res, rejected := run(t, tallier, choices, 2)
+	t.Logf("result %v , rejected %d", res.Counts, rejected)
+	if res.Voters != voters {
+		t.Errorf("voters : %d != %d", res.Voters, voters)
+	}
+	for j := range want {
+		if res.Counts[j] != want[j] {
+			t.Errorf("option %d : %d != %d", j, res.Counts[j], want[j])
+		}
+	}
+	// the duplicated messages are rejected
+	if rejected == 0 {
+		t.Errorf("no duplicated message is rejected")
+	}
+	// the tally is closed
+	b, err := voting.Cast(tallier.Params(), "late", 0)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	if tallier.Submit(b) == nil {
+		t.Errorf("closed : Submit must fail")
+	}
+}
+
+func TestSecureSum(t *testing.T) {
+	tallier, err := voting.NewTallier(context.Background(), "sum-1", 1024, 1, 100)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	choices := []int{1, 0, 1, 1, 0, 1, 0, 0, 1, 1}
+	res, _ := run(t, tallier, choices, 3)
+	if res.Voters != len(choices) || len(res.Counts) != 1 || res.Counts[0] != 6 {
+		t.Errorf("result : %d %v", res.Voters, res.Counts)
+	}
+}
+
+func TestInvalidBallot(t *testing.T) {
+	tallier, err := voting.NewTallier(context.Background(), "election-2", 1024, 2, 10)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	params := tallier.Params()
+	pub := params.PublicKey
+	b, err := voting.Cast(params, "alice", 1)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	// the ballot of 100 votes with the proof of the other ballot
+	c, err := pub.Encryption(big.NewInt(100))
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	forged := &voting.Ballot{ID: b.ID, Voter: "mallory", C: c, Proof: b.Proof}
+	if tallier.Submit(forged) == nil {
+		t.Errorf("forged : Submit must fail")
+	}
+	// the ballot of the other election
+	other := *b
+	other.ID = "election-3"
+	if tallier.Submit(&other) == nil {
+		t.Errorf("other election : Submit must fail")
+	}
+	err = tallier.Submit(b)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	// the replayed ballot by the other voter
+	replayed := *b
+	replayed.Voter = "eve"
+	if tallier.Submit(&replayed) == nil {
+		t.Errorf("replayed : Submit must fail")
+	}
+	// the choice out of range
+	_, err = voting.Cast(params, "bob", 2)
+	if err == nil {
+		t.Errorf("choice = K : Cast must fail")
+	}
+	// the key without the proof of the modulus
+	pub2, _, err := pailliar.KeyGeneration(1024)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	bad := *params
+	bad.PublicKey = pub2
+	if bad.Verify() == nil {
+		t.Errorf("other key : Verify must fail")
+	}
+	// the tally overflows n
+	bad = *params
+	bad.K = 400
+	if bad.Verify() == nil {
+		t.Errorf("overflow : Verify must fail")
+	}
+	res, err := tallier.Tally()
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	if res.Voters != 1 || res.Counts[0] != 0 || res.Counts[1] != 1 {
+		t.Errorf("result : %d %v", res.Voters, res.Counts)
+	}
+}
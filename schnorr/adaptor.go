@@ -0,0 +1,161 @@
+package schnorr
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+
+	"github.com/tnakagawa/goref/ec"
+)
+
+// Adaptor signatures
+// https://github.com/BlockstreamResearch/scriptless-scripts/blob/master/md/atomic-swap.md
+//
+// The pre-signature for the adaptor point T = t⋅G is cbytes(R) || bytes(s'),
+// where R = k'⋅G + T and s' = k + e⋅d with k = k' if has_even_y(R), otherwise k = n - k'.
+// The adapted signature bytes(R) || bytes(s' ± t) is a valid signature with the nonce R or -R.
+
+// AdaptorSign returns the 65-byte pre-signature of m encrypted with the adaptor point T.
+// The secret key d': an integer in the range 1..n-1
+// The message m: a 32-byte array
+// The adaptor point T: a point
+func AdaptorSign(dd *big.Int, m []byte, T *ec.Point) ([]byte, error) {
+	if len(m) != 32 {
+		return nil, fmt.Errorf("illegal message size")
+	}
+	if T == nil || T.Infinite() {
+		return nil, fmt.Errorf("infinite(T)")
+	}
+	d, P, err := keyPair(dd)
+	if err != nil {
+		return nil, err
+	}
+	a := make([]byte, 32)
+	_, err = rand.Read(a)
+	if err != nil {
+		return nil, err
+	}
+	// The nonce commits to the adaptor point T.
+	kd := nonce(d, P, cat(T.Compressed(), m), a)
+	if kd.Sign() == 0 {
+		return nil, fmt.Errorf("k' = 0")
+	}
+	// Let R = k'⋅G + T.
+	R := ec.Add(ec.Mul(kd, ec.G), T)
+	if R.Infinite() {
+		return nil, fmt.Errorf("infinite(R)")
+	}
+	// Let k = k' if has_even_y(R), otherwise let k = n - k' .
+	k := new(big.Int).Set(kd)
+	if !hasEvenY(R) {
+		k.Sub(n, kd)
+	}
+	// Let e = int(hash_BIP0340/challenge(bytes(R) || bytes(P) || m)) mod n.
+	e := challenge(bytes(R.X), bytes(P.X), m)
+	// Let pre-sig = cbytes(R) || bytes((k + ed) mod n).
+	preSig := cat(R.Compressed(), bytes(new(big.Int).Mod(new(big.Int).Add(k, new(big.Int).Mul(e, d)), n)))
+	err = AdaptorVerify(bytes(P.X), m, T, preSig)
+	if err != nil {
+		return nil, err
+	}
+	return preSig, nil
+}
+
+// parsePreSig returns R and s' of the pre-signature.
+func parsePreSig(preSig []byte) (*ec.Point, *big.Int, error) {
+	if len(preSig) != 65 {
+		return nil, nil, fmt.Errorf("illegal pre-signature size")
+	}
+	if preSig[0] != 0x02 && preSig[0] != 0x03 {
+		return nil, nil, fmt.Errorf("illegal pre-signature format")
+	}
+	R, err := LiftX(new(big.Int).SetBytes(preSig[1:33]))
+	if err != nil {
+		return nil, nil, err
+	}
+	if preSig[0] == 0x03 {
+		R = ec.Neg(R)
+	}
+	s := new(big.Int).SetBytes(preSig[33:65])
+	if s.Cmp(n) >= 0 {
+		return nil, nil, fmt.Errorf("s' ≥ n")
+	}
+	return R, s, nil
+}
+
+// AdaptorVerify : The pre-signature is valid for the adaptor point T if and only if the algorithm below does not fail.
+// The public key pk: a 32-byte array
+// The message m: a 32-byte array
+// The adaptor point T: a point
+// The pre-signature pre-sig: a 65-byte array
+func AdaptorVerify(pk, m []byte, T *ec.Point, preSig []byte) error {
+	if len(pk) != 32 {
+		return fmt.Errorf("illegal public key size")
+	}
+	if len(m) != 32 {
+		return fmt.Errorf("illegal message size")
+	}
+	if T == nil || T.Infinite() {
+		return fmt.Errorf("infinite(T)")
+	}
+	P, err := LiftX(new(big.Int).SetBytes(pk))
+	if err != nil {
+		return err
+	}
+	R, s, err := parsePreSig(preSig)
+	if err != nil {
+		return err
+	}
+	// Let e = int(hash_BIP0340/challenge(bytes(R) || bytes(P) || m)) mod n.
+	e := challenge(bytes(R.X), pk, m)
+	// Let R' = s'⋅G - e⋅P.
+	Rd := ec.Add(ec.Mul(s, ec.G), ec.Mul(new(big.Int).Sub(n, e), P))
+	// Fail if R' ≠ R - T when has_even_y(R), or R' ≠ T - R otherwise.
+	expected := ec.Add(R, ec.Neg(T))
+	if !hasEvenY(R) {
+		expected = ec.Neg(expected)
+	}
+	if Rd.Infinite() || expected.Infinite() || Rd.X.Cmp(expected.X) != 0 || Rd.Y.Cmp(expected.Y) != 0 {
+		return fmt.Errorf("s'⋅G - e⋅P ≠ ±(R - T)")
+	}
+	return nil
+}
+
+// Adapt returns the signature bytes(R) || bytes(s' + t) if has_even_y(R), otherwise bytes(R) || bytes(s' - t),
+// from the pre-signature and the adaptor secret t.
+func Adapt(preSig []byte, t *big.Int) ([]byte, error) {
+	R, s, err := parsePreSig(preSig)
+	if err != nil {
+		return nil, err
+	}
+	if hasEvenY(R) {
+		s.Add(s, t)
+	} else {
+		s.Sub(s, t)
+	}
+	return cat(bytes(R.X), bytes(s.Mod(s, n))), nil
+}
+
+// ExtractAdaptorSecret returns the adaptor secret t = s - s' if has_even_y(R), otherwise t = s' - s,
+// from the pre-signature and the signature adapted from it.
+func ExtractAdaptorSecret(preSig, sig []byte) (*big.Int, error) {
+	R, sd, err := parsePreSig(preSig)
+	if err != nil {
+		return nil, err
+	}
+	if len(sig) != 64 {
+		return nil, fmt.Errorf("illegal signature size")
+	}
+	if new(big.Int).SetBytes(sig[0:32]).Cmp(R.X) != 0 {
+		return nil, fmt.Errorf("x(R) ≠ r")
+	}
+	s := new(big.Int).SetBytes(sig[32:64])
+	if s.Cmp(n) >= 0 {
+		return nil, fmt.Errorf("s ≥ n")
+	}
+	t := new(big.Int).Sub(s, sd)
+	if !hasEvenY(R) {
+		t.Neg(t)
+	}
+	return t.Mod(t, n), nil
+}
@@ -0,0 +1,70 @@
+package schnorr_test
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/tnakagawa/goref/ec"
+	"github.com/tnakagawa/goref/schnorr"
+)
+
+func TestAdaptor(t *testing.T) {
+	n, _ := new(big.Int).SetString("FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEBAAEDCE6AF48A03BBFD25E8CD0364141", 16)
+	loop := 8
+	for i := 0; i < loop; i++ {
+		d, err := rand.Int(rand.Reader, n)
+		if err != nil || d.Sign() == 0 {
+			t.Fatalf("%v", err)
+		}
+		pk, err := schnorr.PubKey(d)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		m := make([]byte, 32)
+		rand.Read(m)
+		// adaptor secret and point
+		secret, err := rand.Int(rand.Reader, n)
+		if err != nil || secret.Sign() == 0 {
+			t.Fatalf("%v", err)
+		}
+		T := ec.Mul(secret, ec.G)
+		preSig, err := schnorr.AdaptorSign(d, m, T)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		err = schnorr.AdaptorVerify(pk, m, T, preSig)
+		if err != nil {
+			t.Errorf("%d AdaptorVerify Test Fail / %v", i, err)
+			continue
+		}
+		// the pre-signature is not a signature
+		err = schnorr.Verify(pk, m, preSig[1:])
+		if err == nil {
+			t.Errorf("%d the pre-signature must not be a valid signature", i)
+		}
+		// other adaptor point
+		err = schnorr.AdaptorVerify(pk, m, ec.Add(T, ec.G), preSig)
+		if err == nil {
+			t.Errorf("%d AdaptorVerify must fail with other adaptor point", i)
+		}
+		sig, err := schnorr.Adapt(preSig, secret)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		err = schnorr.Verify(pk, m, sig)
+		if err != nil {
+			t.Errorf("%d Verify Test Fail / %v", i, err)
+			continue
+		}
+		extracted, err := schnorr.ExtractAdaptorSecret(preSig, sig)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		if extracted.Cmp(secret) != 0 {
+			t.Errorf("%d ExtractAdaptorSecret Test Fail / %x != %x", i, extracted, secret)
+			continue
+		}
+		t.Logf("%d Adaptor Test Success / R parity %02x", i, preSig[0])
+	}
+}
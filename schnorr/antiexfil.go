@@ -0,0 +1,83 @@
+package schnorr
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/tnakagawa/goref/ec"
+)
+
+// Anti-Exfil (sign-to-contract) for BIP340 signatures
+//
+// No specification defines sign-to-contract for BIP340, so that this is the construction of this repository.
+// The tags "s2c/schnorr/data" and "s2c/schnorr/point" are chosen here and are not shared with other implementations.
+//
+// The signer fixes the nonce k0 before it learns the host data rho, and signs with
+// k' = k0 + int(hash_s2c/schnorr/point(cbytes(R0) || rho)) mod n, so that it can not choose R to leak the secret key.
+// R0 = k0⋅G is sent with its Y as cbytes(R0), because the tweak commits to it and R0 + t⋅G depends on the Y.
+// R = k'⋅G may have an odd Y, then Sign uses k = n - k' and the signature carries bytes(R) of -R,
+// whose x is the same, so that the host compares only x(R0 + t⋅G) with the first 32 bytes of the signature.
+//  1. The host draws 32 random bytes rho and sends HostCommit(rho) to the signer.
+//  2. The signer sends R0 = SignerCommit(d', m, commitment), the commitment is the auxiliary random data of the nonce.
+//  3. The host sends rho to the signer, and the signer returns AntiExfilSign(d', m, rho).
+//  4. The host checks the signature with AntiExfilHostVerify(pk, m, sig, rho, R0).
+
+// HostCommit returns the commitment to the host data rho.
+func HostCommit(rho []byte) []byte {
+	return TaggedHash("s2c/schnorr/data", rho)
+}
+
+// s2cTweak returns the tweak t = hash(R0 || rho) mod n.
+func s2cTweak(R0 *ec.Point, rho []byte) *big.Int {
+	t := new(big.Int).SetBytes(TaggedHash("s2c/schnorr/point", append(R0.Compressed(), rho...)))
+	return t.Mod(t, n)
+}
+
+// SignerCommit returns the original nonce point R0 that commits to the host commitment.
+// The nonce is derived as in Sign with the host commitment as the auxiliary random data.
+func SignerCommit(dd *big.Int, m, commitment []byte) (*ec.Point, error) {
+	d, P, err := keyPair(dd)
+	if err != nil {
+		return nil, err
+	}
+	return ec.Mul(nonce(d, P, m, commitment), ec.G), nil
+}
+
+// AntiExfilSign returns the signature whose nonce commits to the host data rho.
+func AntiExfilSign(dd *big.Int, m, rho []byte) ([]byte, error) {
+	if len(rho) != 32 {
+		return nil, fmt.Errorf("illegal host data size")
+	}
+	d, P, err := keyPair(dd)
+	if err != nil {
+		return nil, err
+	}
+	// k0 is the nonce of SignerCommit.
+	k0 := nonce(d, P, m, HostCommit(rho))
+	R0 := ec.Mul(k0, ec.G)
+	// k' = k0 + hash(R0 || rho) mod n
+	kd := new(big.Int).Mod(new(big.Int).Add(k0, s2cTweak(R0, rho)), n)
+	return sign(d, P, m, kd)
+}
+
+// AntiExfilHostVerify verifies the signature and that x(R) = x(R0 + hash(R0 || rho)G).
+func AntiExfilHostVerify(pk, m, sig, rho []byte, R0 *ec.Point) error {
+	if len(rho) != 32 {
+		return fmt.Errorf("illegal host data size")
+	}
+	if R0 == nil || R0.Infinite() {
+		return fmt.Errorf("infinite(R0)")
+	}
+	err := Verify(pk, m, sig)
+	if err != nil {
+		return err
+	}
+	R := ec.Add(R0, ec.Mul(s2cTweak(R0, rho), ec.G))
+	if R.Infinite() {
+		return fmt.Errorf("infinite(R)")
+	}
+	if new(big.Int).SetBytes(sig[0:32]).Cmp(R.X) != 0 {
+		return fmt.Errorf("x(R) ≠ x(R0 + hash(R0 || rho)G)")
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package schnorr_test
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/tnakagawa/goref/schnorr"
+)
+
+func TestAntiExfil(t *testing.T) {
+	n, _ := new(big.Int).SetString("FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEBAAEDCE6AF48A03BBFD25E8CD0364141", 16)
+	loop := 10
+	for i := 0; i < loop; i++ {
+		d, err := rand.Int(rand.Reader, n)
+		if err != nil {
+			t.Errorf("%v", err)
+			return
+		}
+		if d.Sign() == 0 {
+			continue
+		}
+		pk, err := schnorr.PubKey(d)
+		if err != nil {
+			t.Errorf("%v", err)
+			return
+		}
+		m := make([]byte, 32)
+		rand.Read(m)
+		// host
+		rho := make([]byte, 32)
+		rand.Read(rho)
+		commitment := schnorr.HostCommit(rho)
+		// signer
+		R0, err := schnorr.SignerCommit(d, m, commitment)
+		if err != nil {
+			t.Errorf("%v", err)
+			return
+		}
+		sig, err := schnorr.AntiExfilSign(d, m, rho)
+		if err != nil {
+			t.Errorf("%v", err)
+			return
+		}
+		// host verify
+		err = schnorr.AntiExfilHostVerify(pk, m, sig, rho, R0)
+		if err != nil {
+			t.Errorf("anti-exfil verify error %v", err)
+			return
+		}
+		// the nonce does not commit to other host data
+		other := make([]byte, 32)
+		rand.Read(other)
+		err = schnorr.AntiExfilHostVerify(pk, m, sig, other, R0)
+		if err == nil {
+			t.Errorf("anti-exfil verify must fail with other host data")
+			return
+		}
+		// the signer does not use the committed nonce
+		sig2, err := schnorr.Sign(d, m, rho)
+		if err != nil {
+			t.Errorf("%v", err)
+			return
+		}
+		err = schnorr.AntiExfilHostVerify(pk, m, sig2, rho, R0)
+		if err == nil {
+			t.Errorf("anti-exfil verify must fail with a plain signature")
+			return
+		}
+	}
+}
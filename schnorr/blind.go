@@ -0,0 +1,220 @@
+package schnorr
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+
+	"github.com/tnakagawa/goref/ec"
+)
+
+// Blind Schnorr signatures
+// The blind signing protocol produces BIP340 signatures that the signer cannot link to the signing sessions.
+//
+// WARNING: The plain blind Schnorr signature is broken by the ROS attack
+// (Benhamouda, Lepoint, Loss, Orrù, Raykova, https://eprint.iacr.org/2020/945)
+// if the signer runs sessions concurrently: a user who opens ℓ ≥ 256 sessions at once
+// can forge ℓ+1 signatures in polynomial time.
+// The signer MUST finish each session (Respond) before opening the next one (Commit),
+// or use the Clause Blind Schnorr variant instead.
+//
+// Clause Blind Schnorr (Fuchsbauer, Wolf, https://eprint.iacr.org/2022/1676)
+// The signer commits to two nonces, the user blinds both challenges,
+// and the signer answers only one of them chosen at random.
+// This variant is secure against the ROS attack with concurrent sessions, at the cost of doubled communication.
+//
+// A session of the signer and the user is used only once, the nonces are erased after Respond.
+//
+// Signer                                   User
+// Commit() → R_0(, R_1)              →
+//                                          Blind(pk, m, R_0(, R_1)) → e_0(, e_1)
+// Respond(e_0(, e_1)) → b, s         ←
+//                                    →     Unblind(b, s) → sig
+
+// randScalar returns a random integer in the range 1..n-1.
+func randScalar() (*big.Int, error) {
+	for {
+		k, err := rand.Int(rand.Reader, n)
+		if err != nil {
+			return nil, err
+		}
+		if k.Sign() != 0 {
+			return k, nil
+		}
+	}
+}
+
+// BlindSigner is the state of the signer in a blind signing session.
+type BlindSigner struct {
+	d      *big.Int   // the secret key, negated if needed
+	ks     []*big.Int // the secret nonces
+	clause bool       // whether the session is Clause Blind Schnorr
+	used   bool       // whether the session has been finished
+}
+
+// NewBlindSigner returns the signer of the plain blind Schnorr signature.
+// WARNING: The sessions must not be run concurrently, see the ROS attack above.
+// The secret key d': an integer in the range 1..n-1
+func NewBlindSigner(dd *big.Int) (*BlindSigner, error) {
+	d, _, err := keyPair(dd)
+	if err != nil {
+		return nil, err
+	}
+	return &BlindSigner{d: d}, nil
+}
+
+// NewClauseBlindSigner returns the signer of the Clause Blind Schnorr signature.
+// The secret key d': an integer in the range 1..n-1
+func NewClauseBlindSigner(dd *big.Int) (*BlindSigner, error) {
+	d, _, err := keyPair(dd)
+	if err != nil {
+		return nil, err
+	}
+	return &BlindSigner{d: d, clause: true}, nil
+}
+
+// Commit returns the public nonces R_j = k_j⋅G, one for the plain variant and two for the clause variant.
+func (bs *BlindSigner) Commit() ([]*ec.Point, error) {
+	if bs.used || bs.ks != nil {
+		return nil, fmt.Errorf("the session has been already used")
+	}
+	l := 1
+	if bs.clause {
+		l = 2
+	}
+	ks := []*big.Int{}
+	Rs := []*ec.Point{}
+	for j := 0; j < l; j++ {
+		k, err := randScalar()
+		if err != nil {
+			return nil, err
+		}
+		ks = append(ks, k)
+		Rs = append(Rs, ec.Mul(k, ec.G))
+	}
+	bs.ks = ks
+	return Rs, nil
+}
+
+// Respond returns the chosen clause b and s = k_b + e_b⋅d mod n for the blinded challenges e_j.
+// The plain variant always chooses b = 0.
+// The nonces are erased, so the session cannot respond twice.
+func (bs *BlindSigner) Respond(es []*big.Int) (int, *big.Int, error) {
+	if bs.used || bs.ks == nil {
+		return 0, nil, fmt.Errorf("the session is not committed or has been already used")
+	}
+	if len(es) != len(bs.ks) {
+		return 0, nil, fmt.Errorf("illegal challenges size")
+	}
+	for _, e := range es {
+		if e.Sign() < 0 || e.Cmp(n) >= 0 {
+			return 0, nil, fmt.Errorf("illegal challenge")
+		}
+	}
+	b := 0
+	if bs.clause {
+		bit, err := rand.Int(rand.Reader, big.NewInt(2))
+		if err != nil {
+			return 0, nil, err
+		}
+		b = int(bit.Int64())
+	}
+	s := new(big.Int).Mul(es[b], bs.d)
+	s.Add(s, bs.ks[b])
+	s.Mod(s, n)
+	bs.ks = nil
+	bs.used = true
+	return b, s, nil
+}
+
+// BlindUser is the state of the user in a blind signing session.
+type BlindUser struct {
+	pk     []byte      // the x-only public key of the signer
+	P      *ec.Point   // the public key of the signer
+	m      []byte      // the message
+	Rs     []*ec.Point // the public nonces of the signer R_j
+	es     []*big.Int  // the blinded challenges e_j
+	alphas []*big.Int  // the blinding factors α_j
+	rs     [][]byte    // the blinded nonces bytes(R'_j)
+	used   bool        // whether the session has been finished
+}
+
+// Blind returns the user and the blinded challenges e_j for the public nonces R_j of the signer.
+// The public key pk: a 32-byte array
+// The message m: a 32-byte array
+// The public nonces R_j: one or two points from the signer
+func Blind(pk, m []byte, Rs []*ec.Point) (*BlindUser, []*big.Int, error) {
+	if len(pk) != 32 {
+		return nil, nil, fmt.Errorf("illegal public key size")
+	}
+	if len(m) != 32 {
+		return nil, nil, fmt.Errorf("illegal message size")
+	}
+	if len(Rs) != 1 && len(Rs) != 2 {
+		return nil, nil, fmt.Errorf("illegal nonces size")
+	}
+	// Let P = lift_x(int(pk)); fail if that fails.
+	P, err := LiftX(new(big.Int).SetBytes(pk))
+	if err != nil {
+		return nil, nil, err
+	}
+	bu := &BlindUser{pk: pk, P: P, m: m, Rs: Rs}
+	for _, R := range Rs {
+		if R == nil || R.Infinite() || !R.OnCurve() {
+			return nil, nil, fmt.Errorf("illegal nonce")
+		}
+		var alpha, beta *big.Int
+		var Rd *ec.Point
+		for {
+			// Choose the random blinding factors α and β.
+			alpha, err = randScalar()
+			if err != nil {
+				return nil, nil, err
+			}
+			beta, err = randScalar()
+			if err != nil {
+				return nil, nil, err
+			}
+			// Let R' = R + α⋅G + β⋅P, and retry until has_even_y(R').
+			Rd = ec.Add(R, ec.Add(ec.Mul(alpha, ec.G), ec.Mul(beta, P)))
+			if !Rd.Infinite() && hasEvenY(Rd) {
+				break
+			}
+		}
+		// Let e' = int(hash_BIP0340/challenge(bytes(R') || bytes(P) || m)) mod n.
+		ed := challenge(bytes(Rd.X), pk, m)
+		// Let e = e' + β mod n.
+		e := new(big.Int).Add(ed, beta)
+		bu.es = append(bu.es, e.Mod(e, n))
+		bu.alphas = append(bu.alphas, alpha)
+		bu.rs = append(bu.rs, bytes(Rd.X))
+	}
+	return bu, bu.es, nil
+}
+
+// Unblind returns the signature bytes(R'_b) || bytes(s + α_b mod n) for the response b, s of the signer.
+func (bu *BlindUser) Unblind(b int, s *big.Int) ([]byte, error) {
+	if bu.used {
+		return nil, fmt.Errorf("the session has been already used")
+	}
+	if b < 0 || b >= len(bu.Rs) {
+		return nil, fmt.Errorf("illegal clause %d", b)
+	}
+	if s.Sign() < 0 || s.Cmp(n) >= 0 {
+		return nil, fmt.Errorf("s ≥ n")
+	}
+	// Fail if s⋅G ≠ R_b + e_b⋅P.
+	if !pointEqual(ec.Mul(s, ec.G), ec.Add(bu.Rs[b], ec.Mul(bu.es[b], bu.P))) {
+		return nil, fmt.Errorf("s⋅G ≠ R + e⋅P")
+	}
+	bu.used = true
+	// Let sig = bytes(R') || bytes(s + α mod n).
+	sd := new(big.Int).Add(s, bu.alphas[b])
+	sig := cat(bu.rs[b], bytes(sd.Mod(sd, n)))
+	// If Verify(pk, m, sig) returns failure, abort.
+	err := Verify(bu.pk, bu.m, sig)
+	if err != nil {
+		return nil, err
+	}
+	return sig, nil
+}
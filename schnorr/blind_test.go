@@ -0,0 +1,79 @@
+package schnorr_test
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/tnakagawa/goref/ec"
+	"github.com/tnakagawa/goref/schnorr"
+)
+
+func TestBlind(t *testing.T) {
+	n, _ := new(big.Int).SetString("FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEBAAEDCE6AF48A03BBFD25E8CD0364141", 16)
+	d, _ := rand.Int(rand.Reader, n)
+	pk, err := schnorr.PubKey(d)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	for _, clause := range []bool{false, true} {
+		for i := 0; i < 4; i++ {
+			var signer *schnorr.BlindSigner
+			if clause {
+				signer, err = schnorr.NewClauseBlindSigner(d)
+			} else {
+				signer, err = schnorr.NewBlindSigner(d)
+			}
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+			Rs, err := signer.Commit()
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+			m := make([]byte, 32)
+			rand.Read(m)
+			user, es, err := schnorr.Blind(pk, m, Rs)
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+			b, s, err := signer.Respond(es)
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+			// the session is used only once
+			_, _, err = signer.Respond(es)
+			if err == nil {
+				t.Errorf("%v %d the signer must not respond twice", clause, i)
+			}
+			// the invalid response
+			_, err = user.Unblind(b, new(big.Int).Add(s, big.NewInt(1)))
+			if err == nil {
+				t.Errorf("%v %d Unblind must fail with the invalid response", clause, i)
+			}
+			sig, err := user.Unblind(b, s)
+			if err != nil {
+				t.Errorf("%v %d Unblind Test Fail / %v", clause, i, err)
+				continue
+			}
+			err = schnorr.Verify(pk, m, sig)
+			if err != nil {
+				t.Errorf("%v %d Verify Test Fail / %v", clause, i, err)
+				continue
+			}
+			// the signature is unlinkable to the session
+			for _, R := range Rs {
+				if R.X.Cmp(new(big.Int).SetBytes(sig[:32])) == 0 {
+					t.Errorf("%v %d the nonce of the signature must be blinded", clause, i)
+				}
+			}
+			t.Logf("%v %d Blind Test Success / clause %d %x", clause, i, b, sig)
+		}
+	}
+	// the nonce not on the curve
+	m := make([]byte, 32)
+	_, _, err = schnorr.Blind(pk, m, []*ec.Point{{X: big.NewInt(1), Y: big.NewInt(1)}})
+	if err == nil {
+		t.Errorf("Blind must fail with the nonce not on the curve")
+	}
+}
@@ -0,0 +1,159 @@
+package schnorr
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/tnakagawa/goref/ec"
+)
+
+// Half-Aggregation of BIP 340 signatures
+// https://github.com/BlockstreamResearch/cross-input-aggregation/blob/master/half-aggregation.mediawiki
+
+// The maximum number of the signatures in the aggregate signature is 2^16 - 1.
+const maxAggregate = 1<<16 - 1
+
+// randomizer returns z_i = int(hash_HalfAgg/randomizer(r_0 || pk_0 || m_0 || ... || r_i || pk_i || m_i)) mod n,
+// where z_0 = 1.
+// The prefix is r_0 || pk_0 || m_0 || ... || r_i || pk_i || m_i.
+func randomizer(i int, prefix []byte) *big.Int {
+	if i == 0 {
+		return big.NewInt(1)
+	}
+	z := new(big.Int).SetBytes(TaggedHash("HalfAgg/randomizer", prefix))
+	return z.Mod(z, n)
+}
+
+// Aggregate returns the aggregate signature of the signatures.
+// The public keys pk_0..u-1: u 32-byte arrays
+// The messages m_0..u-1: u 32-byte arrays
+// The signatures sig_0..u-1: u 64-byte arrays
+func Aggregate(pk, m, sig [][]byte) ([]byte, error) {
+	// Let aggsig = bytes(0)
+	aggsig := bytes(new(big.Int))
+	// Return IncAggregate(aggsig, [], pm_to_agg, sig_to_agg); fail if that fails.
+	return IncAggregate(aggsig, nil, nil, pk, m, sig)
+}
+
+// IncAggregate returns the aggregate signature that aggregates the signatures into the aggregate signature.
+// The aggregate signature aggsig: a 32*(v+1)-byte array
+// The public keys pk_0..v-1 and the messages m_0..v-1 of the aggregate signature: 32-byte arrays
+// The public keys pk_v..v+u-1: u 32-byte arrays
+// The messages m_v..v+u-1: u 32-byte arrays
+// The signatures sig_v..v+u-1: u 64-byte arrays
+func IncAggregate(aggsig []byte, pkAggd, mAggd, pk, m, sig [][]byte) ([]byte, error) {
+	v := len(pkAggd)
+	u := len(pk)
+	if v != len(mAggd) || u != len(m) || u != len(sig) {
+		return nil, fmt.Errorf("illegal parameters size")
+	}
+	// Fail if v + u ≥ 2^16
+	if v+u > maxAggregate {
+		return nil, fmt.Errorf("v + u ≥ 2^16")
+	}
+	// Fail if len(aggsig) ≠ 32 * (v + 1)
+	if len(aggsig) != 32*(v+1) {
+		return nil, fmt.Errorf("len(aggsig) ≠ 32 * (v + 1)")
+	}
+	prefix := []byte{}
+	// For i = 0 .. v-1:
+	for i := 0; i < v; i++ {
+		if len(pkAggd[i]) != 32 || len(mAggd[i]) != 32 {
+			return nil, &BatchError{Index: i, Err: fmt.Errorf("illegal parameters size")}
+		}
+		// Let (pk_i, m_i) = pm_aggd_i
+		// Let r_i = aggsig[i⋅32:(i+1)⋅32]
+		prefix = cat(prefix, aggsig[i*32:(i+1)*32], pkAggd[i], mAggd[i])
+	}
+	// Let s = int(aggsig[v⋅32:(v+1)⋅32]); fail if s ≥ n
+	s := new(big.Int).SetBytes(aggsig[v*32 : (v+1)*32])
+	if s.Cmp(n) >= 0 {
+		return nil, ErrSTooLarge
+	}
+	rs := aggsig[:v*32]
+	// For i = v .. v+u-1:
+	for i := v; i < v+u; i++ {
+		j := i - v
+		if len(pk[j]) != 32 || len(m[j]) != 32 || len(sig[j]) != 64 {
+			return nil, &BatchError{Index: i, Err: fmt.Errorf("illegal parameters size")}
+		}
+		// Let (pk_i, m_i) = pm_to_agg_i-v
+		// Let r_i = sig_to_agg_i-v[0:32]
+		r := sig[j][0:32]
+		// Let s_i = int(sig_to_agg_i-v[32:64]); fail if s_i ≥ n
+		si := new(big.Int).SetBytes(sig[j][32:64])
+		if si.Cmp(n) >= 0 {
+			return nil, &BatchError{Index: i, Err: ErrSTooLarge}
+		}
+		// If i = 0: Let z_i = 1
+		// Else: Let z_i = int(hash_HalfAgg/randomizer(r_0 || pk_0 || m_0 || ... || r_i || pk_i || m_i)) mod n
+		prefix = cat(prefix, r, pk[j], m[j])
+		z := randomizer(i, prefix)
+		// Let s = s + z_i⋅s_i mod n
+		s.Add(s, new(big.Int).Mul(z, si))
+		s.Mod(s, n)
+		rs = cat(rs, r)
+	}
+	// Return r_0 || ... || r_v+u-1 || bytes(s)
+	return cat(rs, bytes(s)), nil
+}
+
+// VerifyAggregate : The aggregate signature is valid if and only if the algorithm below does not fail.
+// The public keys pk_0..u-1: u 32-byte arrays
+// The messages m_0..u-1: u 32-byte arrays
+// The aggregate signature aggsig: a 32*(u+1)-byte array
+func VerifyAggregate(pk, m [][]byte, aggsig []byte) error {
+	u := len(pk)
+	if u != len(m) {
+		return fmt.Errorf("illegal parameters size")
+	}
+	// Fail if u ≥ 2^16
+	if u > maxAggregate {
+		return fmt.Errorf("u ≥ 2^16")
+	}
+	// Fail if len(aggsig) ≠ 32 * (u + 1)
+	if len(aggsig) != 32*(u+1) {
+		return fmt.Errorf("len(aggsig) ≠ 32 * (u + 1)")
+	}
+	prefix := []byte{}
+	zs := []*big.Int{}
+	es := []*big.Int{}
+	Rs := []*ec.Point{}
+	Ps := []*ec.Point{}
+	// For i = 0 .. u-1:
+	for i := 0; i < u; i++ {
+		if len(pk[i]) != 32 || len(m[i]) != 32 {
+			return &BatchError{Index: i, Err: fmt.Errorf("illegal parameters size")}
+		}
+		// Let P_i = lift_x(int(pk_i)); fail if that fails
+		P, err := LiftX(new(big.Int).SetBytes(pk[i]))
+		if err != nil {
+			return &BatchError{Index: i, Err: fmt.Errorf("%w : %v", ErrInvalidPubKey, err)}
+		}
+		Ps = append(Ps, P)
+		// Let r_i = aggsig[i⋅32:(i+1)⋅32]
+		r := aggsig[i*32 : (i+1)*32]
+		// Let R_i = lift_x(int(r_i)); fail if that fails
+		R, err := LiftX(new(big.Int).SetBytes(r))
+		if err != nil {
+			return &BatchError{Index: i, Err: fmt.Errorf("%w : %v", ErrBadR, err)}
+		}
+		Rs = append(Rs, R)
+		// Let e_i = int(hash_BIP0340/challenge(bytes(r_i) || pk_i || m_i)) mod n
+		es = append(es, challenge(r, pk[i], m[i]))
+		// If i = 0: Let z_i = 1
+		// Else: Let z_i = int(hash_HalfAgg/randomizer(r_0 || pk_0 || m_0 || ... || r_i || pk_i || m_i)) mod n
+		prefix = cat(prefix, r, pk[i], m[i])
+		zs = append(zs, randomizer(i, prefix))
+	}
+	// Let s = int(aggsig[u⋅32:(u+1)⋅32]); fail if s ≥ n
+	s := new(big.Int).SetBytes(aggsig[u*32 : (u+1)*32])
+	if s.Cmp(n) >= 0 {
+		return ErrSTooLarge
+	}
+	// Fail if s⋅G ≠ z_0⋅(R_0 + e_0⋅P_0) + ... + z_u-1⋅(R_u-1 + e_u-1⋅P_u-1)
+	if !multiScalarEqual(s, zs, es, Rs, Ps) {
+		return fmt.Errorf("s⋅G ≠ z_0⋅(R_0 + e_0⋅P_0) + ... + z_u-1⋅(R_u-1 + e_u-1⋅P_u-1)")
+	}
+	return nil
+}
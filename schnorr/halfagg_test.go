@@ -0,0 +1,167 @@
+package schnorr_test
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/tnakagawa/goref/schnorr"
+)
+
+func TestHalfAggregation(t *testing.T) {
+	n, _ := new(big.Int).SetString("FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEBAAEDCE6AF48A03BBFD25E8CD0364141", 16)
+	u := 5
+	pks := [][]byte{}
+	msgs := [][]byte{}
+	sigs := [][]byte{}
+	for i := 0; i < u; i++ {
+		d, _ := rand.Int(rand.Reader, n)
+		pk, err := schnorr.PubKey(d)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		m := make([]byte, 32)
+		rand.Read(m)
+		a := make([]byte, 32)
+		rand.Read(a)
+		sig, err := schnorr.Sign(d, m, a)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		pks = append(pks, pk)
+		msgs = append(msgs, m)
+		sigs = append(sigs, sig)
+	}
+	// the empty aggregate signature
+	aggsig, err := schnorr.Aggregate(nil, nil, nil)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	err = schnorr.VerifyAggregate(nil, nil, aggsig)
+	if err != nil {
+		t.Errorf("the empty aggregate signature must be valid / %v", err)
+	}
+	aggsig, err = schnorr.Aggregate(pks, msgs, sigs)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if len(aggsig) != 32+32*u {
+		t.Fatalf("illegal aggregate signature size %d", len(aggsig))
+	}
+	err = schnorr.VerifyAggregate(pks, msgs, aggsig)
+	if err != nil {
+		t.Fatalf("VerifyAggregate Test Fail / %v", err)
+	}
+	t.Logf("VerifyAggregate Test Success / %x", aggsig)
+	// the incremental aggregation is the same as the aggregation at once
+	for v := 0; v <= u; v++ {
+		inc, err := schnorr.Aggregate(pks[:v], msgs[:v], sigs[:v])
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		inc, err = schnorr.IncAggregate(inc, pks[:v], msgs[:v], pks[v:], msgs[v:], sigs[v:])
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		if !bytes.Equal(inc, aggsig) {
+			t.Errorf("%d IncAggregate Test Fail / %x != %x", v, inc, aggsig)
+			continue
+		}
+		t.Logf("%d IncAggregate Test Success", v)
+	}
+	// the wrong message
+	wrong := append([][]byte{}, msgs...)
+	wrong[2] = make([]byte, 32)
+	err = schnorr.VerifyAggregate(pks, wrong, aggsig)
+	if err == nil {
+		t.Errorf("VerifyAggregate must fail with the wrong message")
+	}
+	// the wrong order
+	pks[0], pks[1] = pks[1], pks[0]
+	msgs[0], msgs[1] = msgs[1], msgs[0]
+	err = schnorr.VerifyAggregate(pks, msgs, aggsig)
+	if err == nil {
+		t.Errorf("VerifyAggregate must fail with the wrong order")
+	}
+	// the invalid signature
+	sigs[3] = append(append([]byte{}, sigs[3][:32]...), sigs[4][32:]...)
+	aggsig, err = schnorr.Aggregate(pks, msgs, sigs)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	err = schnorr.VerifyAggregate(pks, msgs, aggsig)
+	if err == nil {
+		t.Errorf("VerifyAggregate must fail with the invalid signature")
+	}
+}
+
+// halfAggVector is the vector of the aggregate signature.
+type halfAggVector struct {
+	PubKeys []string `json:"pubkeys"`
+	Msgs    []string `json:"msgs"`
+	AggSig  string   `json:"aggsig"`
+	Comment string   `json:"comment"`
+}
+
+// The valid vectors are the known-answer vectors of test_verify_vectors in hacspec-halfagg/tests/tests.rs of the draft,
+// https://github.com/BlockstreamResearch/cross-input-aggregation/tree/master/hacspec-halfagg
+// the draft has no invalid vectors, so the invalid vectors are the valid vectors modified.
+func TestHalfAggregationVectors(t *testing.T) {
+	bs, err := os.ReadFile("./halfagg_vectors.json")
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	var vectors struct {
+		Valid   []halfAggVector `json:"valid"`
+		Invalid []halfAggVector `json:"invalid"`
+	}
+	err = json.Unmarshal(bs, &vectors)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	decode := func(hs []string) [][]byte {
+		bss := [][]byte{}
+		for _, h := range hs {
+			b, err := hex.DecodeString(h)
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+			bss = append(bss, b)
+		}
+		return bss
+	}
+	for i, v := range vectors.Valid {
+		pks := decode(v.PubKeys)
+		msgs := decode(v.Msgs)
+		aggsig := decode([]string{v.AggSig})[0]
+		err = schnorr.VerifyAggregate(pks, msgs, aggsig)
+		if err != nil {
+			t.Errorf("%d : VerifyAggregate Test Fail / %v", i, err)
+			continue
+		}
+		// the aggregate signature of one signature is the signature itself
+		if len(pks) == 1 {
+			err = schnorr.Verify(pks[0], msgs[0], aggsig)
+			if err != nil {
+				t.Errorf("%d : Verify Test Fail / %v", i, err)
+			}
+			agg, err := schnorr.Aggregate(pks, msgs, [][]byte{aggsig})
+			if err != nil || !bytes.Equal(agg, aggsig) {
+				t.Errorf("%d : Aggregate Test Fail / %x %v", i, agg, err)
+			}
+		}
+		t.Logf("%d : VerifyAggregate Test Success", i)
+	}
+	for i, v := range vectors.Invalid {
+		err = schnorr.VerifyAggregate(decode(v.PubKeys), decode(v.Msgs), decode([]string{v.AggSig})[0])
+		if err == nil {
+			t.Errorf("%d : VerifyAggregate must fail : %s", i, v.Comment)
+			continue
+		}
+		t.Logf("%d : %s / %v", i, v.Comment, err)
+	}
+}
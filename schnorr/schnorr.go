@@ -1,10 +1,14 @@
 package schnorr
 
+// https://github.com/bitcoin/bips/blob/master/bip-0340.mediawiki
+
 import (
-	"crypto/rand"
+	"encoding/binary"
+	"errors"
 	"fmt"
 	"math/big"
 
+	"github.com/tnakagawa/goref/chacha20poly1305"
 	"github.com/tnakagawa/goref/ec"
 	"github.com/tnakagawa/goref/sha256"
 )
@@ -15,10 +19,33 @@ var p, _ = new(big.Int).SetString("FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF
 // The constant n refers to the curve order, 0xFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEBAAEDCE6AF48A03BBFD25E8CD0364141.
 var n, _ = new(big.Int).SetString("FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEBAAEDCE6AF48A03BBFD25E8CD0364141", 16)
 
-func p2bs(p *ec.Point) []byte {
-	bs := make([]byte, 1)
-	bs[0] = byte(0x02 + p.Y.Bit(0))
-	return append(bs, bytes(p.X)...)
+// The errors of the verification, which can be tested with errors.Is.
+var (
+	// ErrInvalidPubKey is returned if the public key is not the x-coordinate of a point on the curve.
+	ErrInvalidPubKey = errors.New("invalid public key")
+	// ErrRTooLarge is returned if r ≥ p.
+	ErrRTooLarge = errors.New("r ≥ p")
+	// ErrSTooLarge is returned if s ≥ n.
+	ErrSTooLarge = errors.New("s ≥ n")
+	// ErrInfiniteR is returned if R = s⋅G - e⋅P is the point at infinity.
+	ErrInfiniteR = errors.New("infinite(R)")
+	// ErrBadR is returned if not has_even_y(R) or x(R) ≠ r.
+	ErrBadR = errors.New("bad R")
+)
+
+// BatchError is the error of the batch verification, which carries the index of the first failing input.
+type BatchError struct {
+	Index int   // the index of the failing input
+	Err   error // the reason of the failure
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("index %d : %v", e.Index, e.Err)
+}
+
+// Unwrap returns the reason of the failure.
+func (e *BatchError) Unwrap() error {
+	return e.Err
 }
 
 // The function bytes(x), where x is an integer, returns the 32-byte encoding of x, most significant byte first.
@@ -33,11 +60,20 @@ func bytes(x *big.Int) []byte {
 	return bs
 }
 
-// The function lift_x(x), where x is an integer in range 0..p-1,
-// returns the point P for which x(P) = x and y(P) is a quadratic residue modulo p,
-// or fails if no such point exists.
+// The function has_even_y(P), where P is a point for which not is_infinite(P), returns y(P) mod 2 = 0.
+func hasEvenY(P *ec.Point) bool {
+	return P.Y.Bit(0) == 0
+}
+
+// LiftX : The function lift_x(x), where x is a 256-bit unsigned integer,
+// returns the point P for which x(P) = x and has_even_y(P),
+// or fails if x is greater than p-1 or no such point exists.
 // The function lift_x(x) is equivalent to the following pseudocode:
-func liftX(x *big.Int) (*ec.Point, error) {
+func LiftX(x *big.Int) (*ec.Point, error) {
+	// Fail if x ≥ p.
+	if x.Cmp(p) >= 0 {
+		return nil, fmt.Errorf("x ≥ p")
+	}
 	// Let c = x^3 + 7 mod p.
 	c := new(big.Int).Mod(new(big.Int).Add(new(big.Int).Exp(x, big.NewInt(3), p), big.NewInt(7)), p)
 	// Let y = c^{(p+1)/4} mod p.
@@ -46,20 +82,11 @@ func liftX(x *big.Int) (*ec.Point, error) {
 	if c.Cmp(new(big.Int).Exp(y, big.NewInt(2), p)) != 0 {
 		return nil, fmt.Errorf("c ≠ y^2 mod p")
 	}
-	// Return the unique point P such that x(P) = x and y(P) = y.
-	P := &ec.Point{X: x, Y: y}
-	return P, nil
-}
-
-// The function point(x), where x is a 32-byte array, returns the point P = lift_x(int(x)).
-func point(x []byte) (*ec.Point, error) {
-	if len(x) != 32 {
-		return nil, fmt.Errorf("illigal x size")
-	}
-	P, err := liftX(new(big.Int).SetBytes(x))
-	if err != nil {
-		return nil, err
+	// Return the unique point P such that x(P) = x and y(P) = y if y mod 2 = 0 or y(P) = p-y otherwise.
+	if y.Bit(0) != 0 {
+		y.Sub(p, y)
 	}
+	P := &ec.Point{X: new(big.Int).Set(x), Y: y}
 	return P, nil
 }
 
@@ -68,79 +95,219 @@ func hash(x []byte) []byte {
 	return sha256.Digest(x)
 }
 
-// The function jacobi(x), where x is an integer, returns the Jacobi symbol of x / p.
-// It is equal to x^{(p-1)/2} mod p (Euler's criterion).
-func jacobi(x *big.Int) *big.Int {
-	return new(big.Int).Exp(x, new(big.Int).Div(new(big.Int).Sub(p, big.NewInt(1)), big.NewInt(2)), p)
+// TaggedHash : The function hash_name(x), where x is a byte array,
+// returns the 32-byte hash SHA256(SHA256(tag) || SHA256(tag) || x),
+// where tag is the UTF-8 encoding of name.
+func TaggedHash(tag string, x []byte) []byte {
+	t := hash([]byte(tag))
+	return hash(append(append(append([]byte{}, t...), t...), x...))
+}
+
+// The function xor(x, y), where x and y are byte arrays of the same length, returns their bitwise exclusive or.
+func xor(x, y []byte) []byte {
+	z := make([]byte, len(x))
+	for i := range x {
+		z[i] = x[i] ^ y[i]
+	}
+	return z
+}
+
+// cat returns the concatenation of the byte arrays.
+func cat(xs ...[]byte) []byte {
+	bs := []byte{}
+	for _, x := range xs {
+		bs = append(bs, x...)
+	}
+	return bs
+}
+
+// challenge returns e = int(hash_BIP0340/challenge(bytes(R) || bytes(P) || m)) mod n.
+func challenge(r, pk, m []byte) *big.Int {
+	e := new(big.Int).SetBytes(TaggedHash("BIP0340/challenge", cat(r, pk, m)))
+	return e.Mod(e, n)
+}
+
+// keyPair returns the secret key d, which is negated if needed, and the public key P for the secret key d'.
+func keyPair(dd *big.Int) (*big.Int, *ec.Point, error) {
+	// Fail if d' = 0 or d' ≥ n.
+	if dd.Sign() <= 0 || dd.Cmp(n) >= 0 {
+		return nil, nil, fmt.Errorf("d' = 0 or d' ≥ n")
+	}
+	// Let P = d'⋅G
+	P := ec.Mul(dd, ec.G)
+	// Let d = d' if has_even_y(P), otherwise let d = n - d' .
+	d := new(big.Int).Set(dd)
+	if !hasEvenY(P) {
+		d.Sub(n, dd)
+	}
+	return d, P, nil
+}
+
+// nonce returns k' = int(rand) mod n.
+func nonce(d *big.Int, P *ec.Point, m, a []byte) *big.Int {
+	// Let t be the byte-wise xor of bytes(d) and hash_BIP0340/aux(a).
+	t := xor(bytes(d), TaggedHash("BIP0340/aux", a))
+	// Let rand = hash_BIP0340/nonce(t || bytes(P) || m).
+	rnd := TaggedHash("BIP0340/nonce", cat(t, bytes(P.X), m))
+	// Let k' = int(rand) mod n.
+	kd := new(big.Int).SetBytes(rnd)
+	return kd.Mod(kd, n)
+}
+
+// PubKey returns the x-only public key for the secret key d'.
+// The secret key d': an integer in the range 1..n-1
+func PubKey(dd *big.Int) ([]byte, error) {
+	_, P, err := keyPair(dd)
+	if err != nil {
+		return nil, err
+	}
+	// Return bytes(d'⋅G).
+	return bytes(P.X), nil
 }
 
 // Verify : The signature is valid if and only if the algorithm below does not fail.
 // The public key pk: a 32-byte array
-// The message m: a 32-byte array
+// The message m: a byte array
 // A signature sig: a 64-byte array
 func Verify(pk, m, sig []byte) error {
 	if len(pk) != 32 {
-		return fmt.Errorf("illegal public key size")
-	}
-	if len(m) != 32 {
-		return fmt.Errorf("illegal message size")
+		return fmt.Errorf("%w : illegal public key size", ErrInvalidPubKey)
 	}
 	if len(sig) != 64 {
 		return fmt.Errorf("illegal signature size")
 	}
-	// Let P = point(pk); fail if point(pk) fails.
-	P, err := point(pk)
+	// Let P = lift_x(int(pk)); fail if that fails.
+	P, err := LiftX(new(big.Int).SetBytes(pk))
 	if err != nil {
-		return err
+		return fmt.Errorf("%w : %v", ErrInvalidPubKey, err)
 	}
 	// Let r = int(sig[0:32]); fail if r ≥ p.
 	r := new(big.Int).SetBytes(sig[0:32])
 	if r.Cmp(p) >= 0 {
-		return fmt.Errorf("r ≥ p")
+		return ErrRTooLarge
 	}
 	// Let s = int(sig[32:64]); fail if s ≥ n.
 	s := new(big.Int).SetBytes(sig[32:64])
 	if s.Cmp(n) >= 0 {
-		return fmt.Errorf("s ≥ n")
+		return ErrSTooLarge
 	}
-	// Let e = int(hash(bytes(r) || bytes(P) || m)) mod n
-	e := new(big.Int).SetBytes(hash(append(append(bytes(r), bytes(P.X)...), m...)))
-	// Let R = sG - eP.
-	R := ec.Add(ec.Mul(s, ec.G), ec.Mul(new(big.Int).Mod(new(big.Int).Neg(e), n), P))
-	// Fail if infinite(R).
+	// Let e = int(hash_BIP0340/challenge(bytes(r) || bytes(P) || m)) mod n.
+	e := challenge(sig[0:32], pk, m)
+	// Let R = s⋅G - e⋅P.
+	R := ec.Add(ec.Mul(s, ec.G), ec.Mul(new(big.Int).Sub(n, e), P))
+	// Fail if is_infinite(R).
 	if R.Infinite() {
-		return fmt.Errorf("infinite(R)")
+		return ErrInfiniteR
 	}
-	// Fail if jacobi(y(R)) ≠ 1 or x(R) ≠ r.
-	if jacobi(R.Y).Cmp(big.NewInt(1)) != 0 {
-		return fmt.Errorf("jacobi(y(R)) ≠ 1")
+	// Fail if not has_even_y(R).
+	if !hasEvenY(R) {
+		return fmt.Errorf("%w : not has_even_y(R)", ErrBadR)
 	}
+	// Fail if x(R) ≠ r.
 	if R.X.Cmp(r) != 0 {
-		return fmt.Errorf("x(R) ≠ r")
+		return fmt.Errorf("%w : x(R) ≠ r", ErrBadR)
 	}
 	return nil
 }
 
+// seedHash returns seed = seed_hash(pk1..pku || m1..mu || sig1..sigu) instantiated with SHA256.
+// Each message is prefixed with its 8-byte length, so that the variable-length messages are encoded unambiguously.
+func seedHash(pk, m, sig [][]byte) []byte {
+	ms := []byte{}
+	for _, mi := range m {
+		l := make([]byte, 8)
+		binary.BigEndian.PutUint64(l, uint64(len(mi)))
+		ms = cat(ms, l, mi)
+	}
+	return hash(cat(cat(pk...), ms, cat(sig...)))
+}
+
+// randomIntegers returns u-1 integers a2...u in the range 1...n-1,
+// using ChaCha20 with key seed as a CSPRNG to generate 256-bit integers.
+func randomIntegers(seed []byte, u int) ([]*big.Int, error) {
+	iv := make([]byte, 12)
+	as := []*big.Int{}
+	stream := []byte{}
+	counter := uint32(0)
+	for len(as) < u-1 {
+		if len(stream) < 32 {
+			block, err := chacha20poly1305.ChaCha20Block(seed, counter, iv)
+			if err != nil {
+				return nil, err
+			}
+			counter++
+			stream = append(stream, block...)
+		}
+		a := new(big.Int).SetBytes(stream[:32])
+		stream = stream[32:]
+		// skipping integers not in the range 1...n-1.
+		if a.Sign() == 0 || a.Cmp(n) >= 0 {
+			continue
+		}
+		as = append(as, a)
+	}
+	return as, nil
+}
+
 // BatchVerify : All provided signatures are valid with overwhelming probability if and only if the algorithm below does not fail.
 // The number u of signatures
 // The public keys pk1..u: u 32-byte arrays
-// The messages m1..u: u 32-byte arrays
+// The messages m1..u: u byte arrays
 // The signatures sig1..u: u 64-byte arrays
+// If the batch fails, it is bisected to report the index of the first failing input as *BatchError.
 func BatchVerify(pk, m, sig [][]byte) error {
 	u := len(pk)
 	if u != len(m) || u != len(sig) {
 		return fmt.Errorf("illieal parameters size")
 	}
+	for i := 0; i < u; i++ {
+		if len(pk[i]) != 32 || len(sig[i]) != 64 {
+			return &BatchError{Index: i, Err: fmt.Errorf("illegal parameters size")}
+		}
+	}
+	if u == 0 {
+		return nil
+	}
+	err := batchVerify(pk, m, sig)
+	if err == nil {
+		return nil
+	}
+	return bisect(pk, m, sig, 0, err)
+}
+
+// bisect returns the error of the first failing input in the failed batch.
+// The offset is the index of the first input of the batch.
+func bisect(pk, m, sig [][]byte, offset int, err error) error {
+	if len(pk) == 1 {
+		// The single verification tells the reason of the failure.
+		verr := Verify(pk[0], m[0], sig[0])
+		if verr != nil {
+			err = verr
+		}
+		return &BatchError{Index: offset, Err: err}
+	}
+	h := len(pk) / 2
+	err = batchVerify(pk[:h], m[:h], sig[:h])
+	if err != nil {
+		return bisect(pk[:h], m[:h], sig[:h], offset, err)
+	}
+	err = batchVerify(pk[h:], m[h:], sig[h:])
+	if err != nil {
+		return bisect(pk[h:], m[h:], sig[h:], offset+h, err)
+	}
+	// Both halves are valid, which happens only with negligible probability.
+	return fmt.Errorf("batch verification failed")
+}
+
+// batchVerify is the batch verification algorithm.
+func batchVerify(pk, m, sig [][]byte) error {
+	u := len(pk)
 	// Generate u-1 random integers a2...u in the range 1...n-1.
 	// They are generated deterministically using a CSPRNG seeded by a cryptographic hash of all inputs of the algorithm, i.e. seed = seed_hash(pk1..pku || m1..mu || sig1..sigu ).
 	// A safe choice is to instantiate seed_hash with SHA256 and use ChaCha20 with key seed as a CSPRNG to generate 256-bit integers, skipping integers not in the range 1...n-1.
-	as := []*big.Int{}
-	for i := 1; i < u; i++ {
-		a, err := rand.Int(rand.Reader, n)
-		if err != nil {
-			return err
-		}
-		as = append(as, a)
+	as, err := randomIntegers(seedHash(pk, m, sig), u)
+	if err != nil {
+		return err
 	}
 	Ps := []*ec.Point{}
 	ss := []*big.Int{}
@@ -148,74 +315,125 @@ func BatchVerify(pk, m, sig [][]byte) error {
 	Rs := []*ec.Point{}
 	// For i = 1 .. u:
 	for i := 0; i < u; i++ {
-		// Let Pi = point(pki); fail if point(pki) fails.
-		P, err := point(pk[i])
+		// Let Pi = lift_x(int(pki)); fail if it fails.
+		P, err := LiftX(new(big.Int).SetBytes(pk[i]))
 		if err != nil {
-			return err
+			return fmt.Errorf("%w : %v", ErrInvalidPubKey, err)
 		}
 		Ps = append(Ps, P)
-		// Let r = int(sigi[0:32]); fail if r ≥ p.
+		// Let ri = int(sigi[0:32]); fail if ri ≥ p.
 		r := new(big.Int).SetBytes(sig[i][0:32])
 		if r.Cmp(p) >= 0 {
-			return fmt.Errorf("r ≥ p")
+			return ErrRTooLarge
 		}
 		// Let si = int(sigi[32:64]); fail if si ≥ n.
 		s := new(big.Int).SetBytes(sig[i][32:64])
 		if s.Cmp(n) >= 0 {
-			return fmt.Errorf("si ≥ n")
+			return ErrSTooLarge
 		}
 		ss = append(ss, s)
-		// Let ei = int(hash(bytes(r) || bytes(Pi) || mi)) mod n.
-		e := new(big.Int).SetBytes(hash(append(append(bytes(r), bytes(P.X)...), m[i]...)))
+		// Let ei = int(hash_BIP0340/challenge(bytes(ri) || bytes(Pi) || mi)) mod n.
+		e := challenge(sig[i][0:32], pk[i], m[i])
 		es = append(es, e)
-		// Let Ri = lift_x(r); fail if lift_x(r) fails.
-		R, err := liftX(r)
+		// Let Ri = lift_x(ri); fail if lift_x(ri) fails.
+		R, err := LiftX(r)
 		if err != nil {
-			return err
+			return fmt.Errorf("%w : %v", ErrBadR, err)
 		}
 		Rs = append(Rs, R)
 	}
-	// Fail if (s1 + a2s2 + ... + ausu)G ≠ R1 + a2R2 + ... + auRu + e1P1 + (a2e2)P2 + ... + (aueu)Pu.
-	left := ec.Mul(ss[0], ec.G)
-	right := ec.Add(Rs[0], ec.Mul(es[0], Ps[0]))
-	for i := 1; i < u; i++ {
-		left = ec.Add(left, ec.Mul(new(big.Int).Mul(as[i-1], ss[i]), ec.G))
-		right = ec.Add(right, ec.Add(ec.Mul(as[i-1], Rs[i]), ec.Mul(new(big.Int).Mul(as[i-1], es[i]), Ps[i])))
+	// Fail if (s1 + a2s2 + ... + ausu)⋅G ≠ R1 + a2⋅R2 + ... + au⋅Ru + e1⋅P1 + (a2e2)⋅P2 + ... + (aueu)⋅Pu.
+	as = append([]*big.Int{big.NewInt(1)}, as...)
+	s := new(big.Int)
+	for i := 0; i < u; i++ {
+		s.Add(s, new(big.Int).Mul(as[i], ss[i]))
 	}
-	if left.X.Cmp(right.X) != 0 || left.Y.Cmp(right.Y) != 0 {
-		return fmt.Errorf("(s1 + a2s2 + ... + ausu)G ≠ R1 + a2R2 + ... + auRu + e1P1 + (a2e2)P2 + ... + (aueu)Pu")
+	if !multiScalarEqual(s.Mod(s, n), as, es, Rs, Ps) {
+		return fmt.Errorf("(s1 + a2s2 + ... + ausu)⋅G ≠ R1 + a2⋅R2 + ... + au⋅Ru + e1⋅P1 + (a2e2)⋅P2 + ... + (aueu)⋅Pu")
 	}
 	return nil
 }
 
+// multiScalarEqual returns whether s⋅G = a1⋅R1 + ... + au⋅Ru + (a1e1)⋅P1 + ... + (aueu)⋅Pu.
+// It is shared by the batch verification and the verification of the aggregate signature.
+func multiScalarEqual(s *big.Int, as, es []*big.Int, Rs, Ps []*ec.Point) bool {
+	right := &ec.Point{}
+	for i := range as {
+		right = ec.Add(right, ec.Add(ec.Mul(as[i], Rs[i]), ec.Mul(new(big.Int).Mod(new(big.Int).Mul(as[i], es[i]), n), Ps[i])))
+	}
+	return pointEqual(ec.Mul(s, ec.G), right)
+}
+
+// pointEqual returns whether the points P and Q are equal.
+func pointEqual(P, Q *ec.Point) bool {
+	if P.Infinite() || Q.Infinite() {
+		return P.Infinite() && Q.Infinite()
+	}
+	return P.X.Cmp(Q.X) == 0 && P.Y.Cmp(Q.Y) == 0
+}
+
 // Sign is Signing.
-// The secret key d' : an integer in the range 1..n-1
-// The message m: a 32-byte array
-func Sign(dd *big.Int, m []byte) ([]byte, error) {
-	// To sign m for public key bytes(dG):
-	// Let P = d'G
-	P := ec.Mul(dd, ec.G)
-	// Let d = d' if jacobi(y(P)) = 1, otherwise let d = n - d' .
-	d := new(big.Int).Set(dd)
-	if jacobi(P.Y).Cmp(big.NewInt(1)) != 0 {
-		d = new(big.Int).Sub(n, dd)
+// The secret key d': an integer in the range 1..n-1
+// The message m: a byte array
+// Auxiliary random data a: a 32-byte array
+func Sign(dd *big.Int, m, a []byte) ([]byte, error) {
+	if len(a) != 32 {
+		return nil, fmt.Errorf("illegal auxiliary random data size")
+	}
+	d, P, err := keyPair(dd)
+	if err != nil {
+		return nil, err
 	}
-	// Let k' = int(hash(bytes(d) || m)) mod n.
-	kd := new(big.Int).Mod(new(big.Int).SetBytes(hash(append(bytes(d), m...))), n)
+	return sign(d, P, m, nonce(d, P, m, a))
+}
+
+// sign returns the signature of m using the secret key d, its public key P and the nonce k'.
+func sign(d *big.Int, P *ec.Point, m []byte, kd *big.Int) ([]byte, error) {
 	// Fail if k' = 0.
-	if kd.Cmp(big.NewInt(0)) == 0 {
+	if kd.Sign() == 0 {
 		return nil, fmt.Errorf("k' = 0")
 	}
-	// Let R = k'G.
+	// Let R = k'⋅G.
 	R := ec.Mul(kd, ec.G)
-	// Let k = k' if jacobi(y(R)) = 1, otherwise let k = n - k' .
+	// Let k = k' if has_even_y(R), otherwise let k = n - k' .
 	k := new(big.Int).Set(kd)
-	if jacobi(R.Y).Cmp(big.NewInt(1)) != 0 {
-		k = new(big.Int).Sub(n, kd)
+	if !hasEvenY(R) {
+		k.Sub(n, kd)
+	}
+	// Let e = int(hash_BIP0340/challenge(bytes(R) || bytes(P) || m)) mod n.
+	e := challenge(bytes(R.X), bytes(P.X), m)
+	// Let sig = bytes(R) || bytes((k + ed) mod n).
+	sig := cat(bytes(R.X), bytes(new(big.Int).Mod(new(big.Int).Add(k, new(big.Int).Mul(e, d)), n)))
+	// If Verify(bytes(P), m, sig) (see below) returns failure, abort.
+	err := Verify(bytes(P.X), m, sig)
+	if err != nil {
+		return nil, err
 	}
-	// Let e = int(hash(bytes(R) || bytes(P) || m)) mod n.
-	e := new(big.Int).Mod(new(big.Int).SetBytes(hash(append(append(bytes(R.X), bytes(P.X)...), m...))), n)
-	// The signature is bytes(R) || bytes((k + ed) mod n).
-	sig := append(bytes(R.X), bytes(new(big.Int).Mod(new(big.Int).Add(k, new(big.Int).Mul(e, d)), n))...)
+	// Return the signature sig.
 	return sig, nil
 }
+
+// SignTagged signs the message hash_tag(msg) for the domain separation,
+// so that a signature for one application cannot be reused for another.
+// The secret key d': an integer in the range 1..n-1
+// The tag: a non-empty string unique to the application
+// The message msg: a byte array
+// Auxiliary random data a: a 32-byte array
+func SignTagged(dd *big.Int, tag string, msg, a []byte) ([]byte, error) {
+	if tag == "" {
+		return nil, fmt.Errorf("empty tag")
+	}
+	return Sign(dd, TaggedHash(tag, msg), a)
+}
+
+// VerifyTagged verifies the signature of the message hash_tag(msg).
+// The public key pk: a 32-byte array
+// The tag: a non-empty string unique to the application
+// The message msg: a byte array
+// A signature sig: a 64-byte array
+func VerifyTagged(pk []byte, tag string, msg, sig []byte) error {
+	if tag == "" {
+		return fmt.Errorf("empty tag")
+	}
+	return Verify(pk, TaggedHash(tag, msg), sig)
+}
@@ -3,6 +3,7 @@ package schnorr_test
 import (
 	"encoding/csv"
 	"encoding/hex"
+	"errors"
 	"math/big"
 	"os"
 	"reflect"
@@ -12,6 +13,20 @@ import (
 	"github.com/tnakagawa/goref/schnorr"
 )
 
+// verifyErrors is the expected error of the failing test vectors.
+var verifyErrors = map[int]error{
+	5:  schnorr.ErrInvalidPubKey,
+	6:  schnorr.ErrBadR,
+	7:  schnorr.ErrBadR,
+	8:  schnorr.ErrBadR,
+	9:  schnorr.ErrInfiniteR,
+	10: schnorr.ErrInfiniteR,
+	11: schnorr.ErrBadR,
+	12: schnorr.ErrRTooLarge,
+	13: schnorr.ErrSTooLarge,
+	14: schnorr.ErrInvalidPubKey,
+}
+
 func TestVector(t *testing.T) {
 	file, err := os.Open("./test-vectors.csv")
 	if err != nil {
@@ -46,46 +61,51 @@ func TestVector(t *testing.T) {
 			t.Errorf("line[%d] : %+v", idx, err)
 			return
 		}
-		// message
-		m, err := hex.DecodeString(line[3])
+		// aux_rand
+		aux, err := hex.DecodeString(line[3])
 		if err != nil {
 			t.Errorf("line[%d] : %+v", idx, err)
 			return
 		}
-		// signature
-		sig, err := hex.DecodeString(line[4])
+		// message
+		m, err := hex.DecodeString(line[4])
 		if err != nil {
 			t.Errorf("line[%d] : %+v", idx, err)
 			return
 		}
-		// verification result
-		r := line[5] == "TRUE"
+		// signature
+		sig, err := hex.DecodeString(line[5])
 		if err != nil {
 			t.Errorf("line[%d] : %+v", idx, err)
 			return
 		}
+		// verification result
+		r := line[6] == "TRUE"
 		if r {
 			pks = append(pks, pk)
 			ms = append(ms, m)
 			sigs = append(sigs, sig)
 		}
 		// comment
-		c := line[6]
-		// t.Logf("%d %x %x %x %x %v %s", i, s, pk, m, sig, r, c)
+		c := line[7]
 		err = schnorr.Verify(pk, m, sig)
-		// t.Logf("%+v", err)
-		if err == nil && r {
-			err = schnorr.Verify(pk, m, sig)
+		if expected, ok := verifyErrors[i]; ok && !errors.Is(err, expected) {
+			t.Errorf("%02d Verify Test Fail / %+v is not %+v", i, err, expected)
+		} else if err == nil && r {
 			t.Logf("%02d Verify Test Success", i)
 		} else if err != nil && !r {
 			t.Logf("%02d Verify Test Success / %+v / %+v", i, c, err)
 		} else {
-			t.Errorf("%02d Verify Test Fail", i)
+			t.Errorf("%02d Verify Test Fail / %+v", i, err)
 		}
 		if sec != nil {
-			s, err := schnorr.Sign(sec, m)
+			p, err := schnorr.PubKey(sec)
+			if err != nil || !reflect.DeepEqual(p, pk) {
+				t.Errorf("%02d PubKey Test Fail", i)
+			}
+			s, err := schnorr.Sign(sec, m, aux)
 			if err != nil {
-				t.Errorf("%02d Sign   Test Fail", i)
+				t.Errorf("%02d Sign   Test Fail / %+v", i, err)
 			}
 			if reflect.DeepEqual(s, sig) {
 				t.Logf("%02d Sign   Test Success", i)
@@ -101,3 +121,94 @@ func TestVector(t *testing.T) {
 		t.Errorf("BatchVerify Test Fail / %+v", err)
 	}
 }
+
+func TestBatchVerify(t *testing.T) {
+	pks := [][]byte{}
+	ms := [][]byte{}
+	sigs := [][]byte{}
+	for i := 1; i <= 8; i++ {
+		d := big.NewInt(int64(i))
+		pk, err := schnorr.PubKey(d)
+		if err != nil {
+			t.Errorf("%+v", err)
+			return
+		}
+		m := make([]byte, 32)
+		m[0] = byte(i)
+		sig, err := schnorr.Sign(d, m, make([]byte, 32))
+		if err != nil {
+			t.Errorf("%+v", err)
+			return
+		}
+		pks = append(pks, pk)
+		ms = append(ms, m)
+		sigs = append(sigs, sig)
+	}
+	err := schnorr.BatchVerify(pks, ms, sigs)
+	if err != nil {
+		t.Errorf("BatchVerify Test Fail / %+v", err)
+		return
+	}
+	for _, idx := range []int{0, 3, 7} {
+		sig := append([]byte{}, sigs[idx]...)
+		sig[63] ^= 0x01
+		bad := append(append(append([][]byte{}, sigs[:idx]...), sig), sigs[idx+1:]...)
+		err = schnorr.BatchVerify(pks, ms, bad)
+		if err == nil {
+			t.Errorf("BatchVerify must fail at %d", idx)
+			return
+		}
+		var berr *schnorr.BatchError
+		if !errors.As(err, &berr) || berr.Index != idx {
+			t.Errorf("BatchVerify reports another index %d / %+v", idx, err)
+			return
+		}
+		if !errors.Is(err, schnorr.ErrBadR) {
+			t.Errorf("BatchVerify reports another reason %d / %+v", idx, err)
+			return
+		}
+		t.Logf("%+v", err)
+	}
+}
+
+func TestTagged(t *testing.T) {
+	d := big.NewInt(3)
+	pk, err := schnorr.PubKey(d)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	msgs := [][]byte{{}, []byte("hello"), make([]byte, 100)}
+	for i, msg := range msgs {
+		sig, err := schnorr.SignTagged(d, "goref/test", msg, make([]byte, 32))
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		err = schnorr.VerifyTagged(pk, "goref/test", msg, sig)
+		if err != nil {
+			t.Errorf("%d VerifyTagged Test Fail / %+v", i, err)
+			continue
+		}
+		err = schnorr.Verify(pk, schnorr.TaggedHash("goref/test", msg), sig)
+		if err != nil {
+			t.Errorf("%d Verify Test Fail / %+v", i, err)
+			continue
+		}
+		// the signature is bound to the tag
+		err = schnorr.VerifyTagged(pk, "goref/other", msg, sig)
+		if !errors.Is(err, schnorr.ErrBadR) {
+			t.Errorf("%d VerifyTagged must fail with another tag / %+v", i, err)
+			continue
+		}
+		// the signature is not valid for the untagged message
+		err = schnorr.Verify(pk, msg, sig)
+		if err == nil {
+			t.Errorf("%d Verify must fail with the untagged message", i)
+			continue
+		}
+		t.Logf("%d Tagged Test Success / %x", i, sig)
+	}
+	_, err = schnorr.SignTagged(d, "", msgs[1], make([]byte, 32))
+	if err == nil {
+		t.Errorf("SignTagged must fail with the empty tag")
+	}
+}
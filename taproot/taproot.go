@@ -0,0 +1,148 @@
+package taproot
+
+// https://github.com/bitcoin/bips/blob/master/bip-0341.mediawiki
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/tnakagawa/goref/bech32m"
+	"github.com/tnakagawa/goref/ec"
+	"github.com/tnakagawa/goref/schnorr"
+)
+
+// The constant n refers to the curve order, 0xFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEBAAEDCE6AF48A03BBFD25E8CD0364141.
+var n, _ = new(big.Int).SetString("FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEBAAEDCE6AF48A03BBFD25E8CD0364141", 16)
+
+// WitnessVersion is the segwit version of the taproot outputs.
+const WitnessVersion = 1
+
+// The function bytes(x), where x is an integer, returns the 32-byte encoding of x, most significant byte first.
+func bytes(x *big.Int) []byte {
+	bs := x.Bytes()
+	if len(bs) < 32 {
+		bs = append(make([]byte, 32-len(bs)), bs...)
+	}
+	return bs
+}
+
+// TapTweak returns hash_TapTweak(pubkey || h).
+// The internal public key pubkey: a 32-byte array
+// The script tree merkle root h: a 32-byte array, or an empty array if there is no script path
+func TapTweak(pubkey, h []byte) []byte {
+	return schnorr.TaggedHash("TapTweak", append(append([]byte{}, pubkey...), h...))
+}
+
+// tweak returns the tweak t = int(hash_TapTweak(pubkey || h)).
+func tweak(pubkey, h []byte) (*big.Int, error) {
+	if len(pubkey) != 32 {
+		return nil, fmt.Errorf("illegal public key size")
+	}
+	if len(h) != 0 && len(h) != 32 {
+		return nil, fmt.Errorf("illegal merkle root size")
+	}
+	t := new(big.Int).SetBytes(TapTweak(pubkey, h))
+	// if t >= SECP256K1_ORDER: raise ValueError
+	if t.Cmp(n) >= 0 {
+		return nil, fmt.Errorf("t ≥ n")
+	}
+	return t, nil
+}
+
+// TweakPubKey returns the parity of the output key Q and the x-only output key x(Q).
+// The internal public key pubkey: a 32-byte array
+// The script tree merkle root h: a 32-byte array, or an empty array if there is no script path
+func TweakPubKey(pubkey, h []byte) (byte, []byte, error) {
+	t, err := tweak(pubkey, h)
+	if err != nil {
+		return 0, nil, err
+	}
+	// P = lift_x(int_from_bytes(pubkey))
+	P, err := schnorr.LiftX(new(big.Int).SetBytes(pubkey))
+	if err != nil {
+		return 0, nil, err
+	}
+	// Q = point_add(P, point_mul(G, t))
+	Q := ec.Add(P, ec.Mul(t, ec.G))
+	if Q.Infinite() {
+		return 0, nil, fmt.Errorf("infinite(Q)")
+	}
+	// return 0 if has_even_y(Q) else 1, bytes_from_int(x(Q))
+	return byte(Q.Y.Bit(0)), bytes(Q.X), nil
+}
+
+// TweakSecKey returns the tweaked secret key for the key path spending.
+// The internal secret key seckey0: an integer in the range 1..n-1
+// The script tree merkle root h: a 32-byte array, or an empty array if there is no script path
+func TweakSecKey(seckey0 *big.Int, h []byte) (*big.Int, error) {
+	if seckey0.Sign() <= 0 || seckey0.Cmp(n) >= 0 {
+		return nil, fmt.Errorf("seckey0 = 0 or seckey0 ≥ n")
+	}
+	// P = point_mul(G, seckey0)
+	P := ec.Mul(seckey0, ec.G)
+	// seckey = seckey0 if has_even_y(P) else SECP256K1_ORDER - seckey0
+	seckey := new(big.Int).Set(seckey0)
+	if P.Y.Bit(0) != 0 {
+		seckey.Sub(n, seckey0)
+	}
+	// t = int_from_bytes(tagged_hash("TapTweak", bytes_from_int(x(P)) + h))
+	t, err := tweak(bytes(P.X), h)
+	if err != nil {
+		return nil, err
+	}
+	// return bytes_from_int((seckey + t) % SECP256K1_ORDER)
+	seckey.Add(seckey, t)
+	seckey.Mod(seckey, n)
+	if seckey.Sign() == 0 {
+		return nil, fmt.Errorf("seckey = 0")
+	}
+	return seckey, nil
+}
+
+// Sign returns the BIP340 signature of the message m with the tweaked secret key,
+// which is valid for the x-only output key.
+// The internal secret key seckey0: an integer in the range 1..n-1
+// The script tree merkle root h: a 32-byte array, or an empty array if there is no script path
+// The message m: a 32-byte array
+// Auxiliary random data a: a 32-byte array
+func Sign(seckey0 *big.Int, h, m, a []byte) ([]byte, error) {
+	seckey, err := TweakSecKey(seckey0, h)
+	if err != nil {
+		return nil, err
+	}
+	return schnorr.Sign(seckey, m, a)
+}
+
+// WitnessProgram returns the witness program of the taproot output, which is the x-only output key.
+// The internal public key pubkey: a 32-byte array
+// The script tree merkle root h: a 32-byte array, or an empty array if there is no script path
+func WitnessProgram(pubkey, h []byte) ([]byte, error) {
+	_, Q, err := TweakPubKey(pubkey, h)
+	if err != nil {
+		return nil, err
+	}
+	return Q, nil
+}
+
+// ScriptPubKey returns the scriptPubKey of the taproot output, OP_1 0x20 <x(Q)>.
+// The internal public key pubkey: a 32-byte array
+// The script tree merkle root h: a 32-byte array, or an empty array if there is no script path
+func ScriptPubKey(pubkey, h []byte) ([]byte, error) {
+	program, err := WitnessProgram(pubkey, h)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{0x50 + WitnessVersion, byte(len(program))}, program...), nil
+}
+
+// Address returns the bech32m encoded segwit v1 address of the taproot output.
+// The human-readable part hrp: "bc" for mainnet, "tb" for testnet
+// The internal public key pubkey: a 32-byte array
+// The script tree merkle root h: a 32-byte array, or an empty array if there is no script path
+func Address(hrp string, pubkey, h []byte) (string, error) {
+	program, err := WitnessProgram(pubkey, h)
+	if err != nil {
+		return "", err
+	}
+	return bech32m.SegwitAddrEncode(hrp, WitnessVersion, program)
+}
@@ -0,0 +1,160 @@
+package taproot_test
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"testing"
+
+	"github.com/tnakagawa/goref/schnorr"
+	"github.com/tnakagawa/goref/taproot"
+)
+
+type scriptPubKeyVector struct {
+	Given struct {
+		InternalPubkey string          `json:"internalPubkey"`
+		ScriptTree     json.RawMessage `json:"scriptTree"`
+	} `json:"given"`
+	Intermediary struct {
+		LeafHashes    []string `json:"leafHashes"`
+		MerkleRoot    string   `json:"merkleRoot"`
+		Tweak         string   `json:"tweak"`
+		TweakedPubkey string   `json:"tweakedPubkey"`
+	} `json:"intermediary"`
+	Expected struct {
+		ScriptPubKey            string   `json:"scriptPubKey"`
+		Bip350Address           string   `json:"bip350Address"`
+		ScriptPathControlBlocks []string `json:"scriptPathControlBlocks"`
+	} `json:"expected"`
+}
+
+type inputSpendingVector struct {
+	Given struct {
+		TxinIndex       int    `json:"txinIndex"`
+		InternalPrivkey string `json:"internalPrivkey"`
+		MerkleRoot      string `json:"merkleRoot"`
+		HashType        int    `json:"hashType"`
+	} `json:"given"`
+	Intermediary struct {
+		InternalPubkey string `json:"internalPubkey"`
+		Tweak          string `json:"tweak"`
+		TweakedPrivkey string `json:"tweakedPrivkey"`
+		SigHash        string `json:"sigHash"`
+	} `json:"intermediary"`
+	Expected struct {
+		Witness []string `json:"witness"`
+	} `json:"expected"`
+}
+
+type walletTestVectors struct {
+	ScriptPubKey    []*scriptPubKeyVector `json:"scriptPubKey"`
+	KeyPathSpending []struct {
+		InputSpending []*inputSpendingVector `json:"inputSpending"`
+	} `json:"keyPathSpending"`
+}
+
+func readVectors(t *testing.T) *walletTestVectors {
+	bs, err := ioutil.ReadFile("wallet-test-vectors.json")
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	vectors := &walletTestVectors{}
+	err = json.Unmarshal(bs, vectors)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	return vectors
+}
+
+func TestScriptPubKey(t *testing.T) {
+	vectors := readVectors(t)
+	for i, v := range vectors.ScriptPubKey {
+		pubkey, _ := hex.DecodeString(v.Given.InternalPubkey)
+		h, _ := hex.DecodeString(v.Intermediary.MerkleRoot)
+		tweak := hex.EncodeToString(taproot.TapTweak(pubkey, h))
+		if tweak != v.Intermediary.Tweak {
+			t.Errorf("%d tweak %s != %s", i, tweak, v.Intermediary.Tweak)
+			continue
+		}
+		program, err := taproot.WitnessProgram(pubkey, h)
+		if err != nil {
+			t.Errorf("%d %v", i, err)
+			continue
+		}
+		if hex.EncodeToString(program) != v.Intermediary.TweakedPubkey {
+			t.Errorf("%d tweakedPubkey %x != %s", i, program, v.Intermediary.TweakedPubkey)
+			continue
+		}
+		script, err := taproot.ScriptPubKey(pubkey, h)
+		if err != nil {
+			t.Errorf("%d %v", i, err)
+			continue
+		}
+		if hex.EncodeToString(script) != v.Expected.ScriptPubKey {
+			t.Errorf("%d scriptPubKey %x != %s", i, script, v.Expected.ScriptPubKey)
+			continue
+		}
+		addr, err := taproot.Address("bc", pubkey, h)
+		if err != nil {
+			t.Errorf("%d %v", i, err)
+			continue
+		}
+		if addr != v.Expected.Bip350Address {
+			t.Errorf("%d address %s != %s", i, addr, v.Expected.Bip350Address)
+			continue
+		}
+		t.Logf("%d ScriptPubKey Test Success / %s", i, addr)
+	}
+}
+
+func TestKeyPathSpending(t *testing.T) {
+	vectors := readVectors(t)
+	for _, kps := range vectors.KeyPathSpending {
+		for _, v := range kps.InputSpending {
+			i := v.Given.TxinIndex
+			seckey0, _ := new(big.Int).SetString(v.Given.InternalPrivkey, 16)
+			h, _ := hex.DecodeString(v.Given.MerkleRoot)
+			seckey, err := taproot.TweakSecKey(seckey0, h)
+			if err != nil {
+				t.Errorf("%d %v", i, err)
+				continue
+			}
+			if hex.EncodeToString(bytes32(seckey)) != v.Intermediary.TweakedPrivkey {
+				t.Errorf("%d tweakedPrivkey %x != %s", i, seckey, v.Intermediary.TweakedPrivkey)
+				continue
+			}
+			pubkey, _ := hex.DecodeString(v.Intermediary.InternalPubkey)
+			program, err := taproot.WitnessProgram(pubkey, h)
+			if err != nil {
+				t.Errorf("%d %v", i, err)
+				continue
+			}
+			m, _ := hex.DecodeString(v.Intermediary.SigHash)
+			sig, err := taproot.Sign(seckey0, h, m, make([]byte, 32))
+			if err != nil {
+				t.Errorf("%d %v", i, err)
+				continue
+			}
+			// The hash type is appended unless it is SIGHASH_DEFAULT.
+			if v.Given.HashType != 0 {
+				sig = append(sig, byte(v.Given.HashType))
+			}
+			if hex.EncodeToString(sig) != v.Expected.Witness[0] {
+				t.Errorf("%d witness %x != %s", i, sig, v.Expected.Witness[0])
+				continue
+			}
+			err = schnorr.Verify(program, m, sig[:64])
+			if err != nil {
+				t.Errorf("%d %v", i, err)
+				continue
+			}
+			t.Logf("%d KeyPathSpending Test Success / %x", i, sig)
+		}
+	}
+}
+
+func bytes32(x *big.Int) []byte {
+	bs := x.Bytes()
+	return append(make([]byte, 32-len(bs)), bs...)
+}
@@ -0,0 +1,233 @@
+package taproot
+
+import (
+	bs "bytes"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/tnakagawa/goref/ec"
+	"github.com/tnakagawa/goref/schnorr"
+)
+
+// LeafVersionTapScript is the leaf version of the tapscript defined in BIP342.
+const LeafVersionTapScript = 0xc0
+
+// MaxDepth is the maximum depth of the script tree, which is the maximum number of the hashes in the control block.
+const MaxDepth = 128
+
+// Leaf is a leaf of the script tree.
+type Leaf struct {
+	Version byte   // the leaf version, an even number
+	Script  []byte // the script
+	Weight  int    // the relative probability of spending by this leaf, used to build the Huffman tree
+}
+
+// Tree is a node of the script tree, which is either a leaf or a branch.
+type Tree struct {
+	Leaf  *Leaf // the leaf, or nil if the node is a branch
+	Left  *Tree // the left child of the branch
+	Right *Tree // the right child of the branch
+}
+
+// compactSize returns the CompactSize encoding of the length l.
+func compactSize(l int) []byte {
+	switch {
+	case l < 0xfd:
+		return []byte{byte(l)}
+	case l <= 0xffff:
+		b := make([]byte, 3)
+		b[0] = 0xfd
+		binary.LittleEndian.PutUint16(b[1:], uint16(l))
+		return b
+	case l <= 0xffffffff:
+		b := make([]byte, 5)
+		b[0] = 0xfe
+		binary.LittleEndian.PutUint32(b[1:], uint32(l))
+		return b
+	}
+	b := make([]byte, 9)
+	b[0] = 0xff
+	binary.LittleEndian.PutUint64(b[1:], uint64(l))
+	return b
+}
+
+// TapLeaf returns hash_TapLeaf(v || compact_size(size of s) || s).
+// The leaf version v: a byte
+// The script s: a byte array
+func TapLeaf(v byte, s []byte) []byte {
+	return schnorr.TaggedHash("TapLeaf", append(append([]byte{v}, compactSize(len(s))...), s...))
+}
+
+// TapBranch returns hash_TapBranch(a || b) if a < b, otherwise hash_TapBranch(b || a).
+// The child hashes a and b: 32-byte arrays
+func TapBranch(a, b []byte) []byte {
+	if bs.Compare(b, a) < 0 {
+		a, b = b, a
+	}
+	return schnorr.TaggedHash("TapBranch", append(append([]byte{}, a...), b...))
+}
+
+// NewLeaf returns the script tree consisting of only the leaf with the version v and the script s.
+func NewLeaf(v byte, s []byte) *Tree {
+	return &Tree{Leaf: &Leaf{Version: v, Script: s, Weight: 1}}
+}
+
+// NewBranch returns the script tree whose children are left and right.
+func NewBranch(left, right *Tree) *Tree {
+	return &Tree{Left: left, Right: right}
+}
+
+// Huffman returns the script tree built from the leaves by the Huffman algorithm,
+// so that the leaves with the larger weights have the shorter control blocks.
+func Huffman(leaves []*Leaf) (*Tree, error) {
+	if len(leaves) == 0 {
+		return nil, fmt.Errorf("no leaves")
+	}
+	type node struct {
+		tree   *Tree
+		weight int
+	}
+	nodes := []*node{}
+	for _, leaf := range leaves {
+		if leaf.Weight <= 0 {
+			return nil, fmt.Errorf("illegal weight %d", leaf.Weight)
+		}
+		nodes = append(nodes, &node{tree: &Tree{Leaf: leaf}, weight: leaf.Weight})
+	}
+	for len(nodes) > 1 {
+		// The stable sort keeps the given order of the nodes with the same weight.
+		sort.SliceStable(nodes, func(i, j int) bool { return nodes[i].weight < nodes[j].weight })
+		a, b := nodes[0], nodes[1]
+		nodes = append(nodes[2:], &node{tree: NewBranch(a.tree, b.tree), weight: a.weight + b.weight})
+	}
+	tree := nodes[0].tree
+	err := tree.validate(0)
+	if err != nil {
+		return nil, err
+	}
+	return tree, nil
+}
+
+// validate checks the leaf versions and the depth of the script tree.
+func (t *Tree) validate(depth int) error {
+	if depth > MaxDepth {
+		return fmt.Errorf("depth > %d", MaxDepth)
+	}
+	if t.Leaf != nil {
+		if t.Leaf.Version&0xfe != t.Leaf.Version {
+			return fmt.Errorf("illegal leaf version %02x", t.Leaf.Version)
+		}
+		return nil
+	}
+	if t.Left == nil || t.Right == nil {
+		return fmt.Errorf("illegal branch")
+	}
+	err := t.Left.validate(depth + 1)
+	if err != nil {
+		return err
+	}
+	return t.Right.validate(depth + 1)
+}
+
+// Hash returns the TapLeaf hash of the leaf, or the TapBranch hash of the branch.
+// The hash of the root is the merkle root h of the script tree.
+func (t *Tree) Hash() []byte {
+	if t.Leaf != nil {
+		return TapLeaf(t.Leaf.Version, t.Leaf.Script)
+	}
+	return TapBranch(t.Left.Hash(), t.Right.Hash())
+}
+
+// Leaves returns the leaves of the script tree from left to right.
+func (t *Tree) Leaves() []*Leaf {
+	if t.Leaf != nil {
+		return []*Leaf{t.Leaf}
+	}
+	return append(t.Left.Leaves(), t.Right.Leaves()...)
+}
+
+// path returns the hashes of the siblings from the leaf to the root, or nil if the leaf is not in the script tree.
+func (t *Tree) path(leaf *Leaf) [][]byte {
+	if t.Leaf != nil {
+		if t.Leaf == leaf {
+			return [][]byte{}
+		}
+		return nil
+	}
+	if p := t.Left.path(leaf); p != nil {
+		return append(p, t.Right.Hash())
+	}
+	if p := t.Right.path(leaf); p != nil {
+		return append(p, t.Left.Hash())
+	}
+	return nil
+}
+
+// ControlBlock returns the control block for spending the output by the leaf of the script tree.
+// The internal public key pubkey: a 32-byte array
+// The leaf: a leaf of the script tree
+func (t *Tree) ControlBlock(pubkey []byte, leaf *Leaf) ([]byte, error) {
+	err := t.validate(0)
+	if err != nil {
+		return nil, err
+	}
+	path := t.path(leaf)
+	if path == nil {
+		return nil, fmt.Errorf("the leaf is not in the script tree")
+	}
+	parity, _, err := TweakPubKey(pubkey, t.Hash())
+	if err != nil {
+		return nil, err
+	}
+	// bytes([output_key_y_parity + leaf_version]) + internal_pubkey + path
+	c := append([]byte{leaf.Version + parity}, pubkey...)
+	for _, h := range path {
+		c = append(c, h...)
+	}
+	return c, nil
+}
+
+// VerifyControlBlock : The script s is committed to in the output key q if and only if the algorithm below does not fail.
+// The x-only output key q: a 32-byte array
+// The script s: a byte array
+// The control block c: a byte array
+func VerifyControlBlock(q, s, c []byte) error {
+	if len(q) != 32 {
+		return fmt.Errorf("illegal output key size")
+	}
+	// The control block c must have length 33 + 32m, for a value of m that is an integer between 0 and 128, inclusive.
+	if len(c) < 33 || (len(c)-33)%32 != 0 || (len(c)-33)/32 > MaxDepth {
+		return fmt.Errorf("illegal control block size")
+	}
+	// Let p = c[1:33] and let P = lift_x(int(p)).
+	p := c[1:33]
+	P, err := schnorr.LiftX(new(big.Int).SetBytes(p))
+	if err != nil {
+		return err
+	}
+	// Let v = c[0] & 0xfe and call it the leaf version.
+	v := c[0] & 0xfe
+	// Let k0 = hash_TapLeaf(v || compact_size(size of s) || s).
+	k := TapLeaf(v, s)
+	// For j in [0,1,...,m-1]:
+	for j := 33; j < len(c); j += 32 {
+		// Let ej = c[33+32j:65+32j].
+		// Let kj+1 depend on whether kj < ej (lexicographically).
+		k = TapBranch(k, c[j:j+32])
+	}
+	// Let t = hash_TapTweak(p || km).
+	// If t ≥ 0xFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEBAAEDCE6AF48A03BBFD25E8CD0364141 (order of secp256k1), fail.
+	t, err := tweak(p, k)
+	if err != nil {
+		return err
+	}
+	// Let Q = P + int(t)G.
+	Q := ec.Add(P, ec.Mul(t, ec.G))
+	// If q ≠ x(Q) or c[0] & 1 ≠ y(Q) mod 2, fail.
+	if Q.Infinite() || bs.Compare(q, bytes(Q.X)) != 0 || uint(c[0]&1) != Q.Y.Bit(0) {
+		return fmt.Errorf("q ≠ x(Q) or c[0] & 1 ≠ y(Q) mod 2")
+	}
+	return nil
+}
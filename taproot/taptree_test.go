@@ -0,0 +1,162 @@
+package taproot_test
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/tnakagawa/goref/taproot"
+)
+
+type leafVector struct {
+	ID          int    `json:"id"`
+	Script      string `json:"script"`
+	LeafVersion byte   `json:"leafVersion"`
+}
+
+// parseTree returns the script tree of the vector and the leaves indexed by the ids.
+func parseTree(raw json.RawMessage, leaves map[int]*taproot.Leaf) (*taproot.Tree, error) {
+	children := []json.RawMessage{}
+	if json.Unmarshal(raw, &children) == nil {
+		if len(children) != 2 {
+			return nil, fmt.Errorf("illegal branch")
+		}
+		left, err := parseTree(children[0], leaves)
+		if err != nil {
+			return nil, err
+		}
+		right, err := parseTree(children[1], leaves)
+		if err != nil {
+			return nil, err
+		}
+		return taproot.NewBranch(left, right), nil
+	}
+	v := &leafVector{}
+	err := json.Unmarshal(raw, v)
+	if err != nil {
+		return nil, err
+	}
+	script, err := hex.DecodeString(v.Script)
+	if err != nil {
+		return nil, err
+	}
+	tree := taproot.NewLeaf(v.LeafVersion, script)
+	leaves[v.ID] = tree.Leaf
+	return tree, nil
+}
+
+func TestScriptTree(t *testing.T) {
+	vectors := readVectors(t)
+	for i, v := range vectors.ScriptPubKey {
+		if string(v.Given.ScriptTree) == "null" {
+			continue
+		}
+		leaves := map[int]*taproot.Leaf{}
+		tree, err := parseTree(v.Given.ScriptTree, leaves)
+		if err != nil {
+			t.Fatalf("%d %v", i, err)
+		}
+		for id, expected := range v.Intermediary.LeafHashes {
+			leaf := leaves[id]
+			h := hex.EncodeToString(taproot.TapLeaf(leaf.Version, leaf.Script))
+			if h != expected {
+				t.Errorf("%d leafHash %d %s != %s", i, id, h, expected)
+			}
+		}
+		root := tree.Hash()
+		if hex.EncodeToString(root) != v.Intermediary.MerkleRoot {
+			t.Errorf("%d merkleRoot %x != %s", i, root, v.Intermediary.MerkleRoot)
+			continue
+		}
+		pubkey, _ := hex.DecodeString(v.Given.InternalPubkey)
+		q, _ := hex.DecodeString(v.Intermediary.TweakedPubkey)
+		for id, expected := range v.Expected.ScriptPathControlBlocks {
+			leaf := leaves[id]
+			c, err := tree.ControlBlock(pubkey, leaf)
+			if err != nil {
+				t.Errorf("%d %d %v", i, id, err)
+				continue
+			}
+			if hex.EncodeToString(c) != expected {
+				t.Errorf("%d controlBlock %d %x != %s", i, id, c, expected)
+				continue
+			}
+			err = taproot.VerifyControlBlock(q, leaf.Script, c)
+			if err != nil {
+				t.Errorf("%d %d %v", i, id, err)
+				continue
+			}
+			// the control block does not commit to the other script
+			err = taproot.VerifyControlBlock(q, append(leaf.Script, 0x51), c)
+			if err == nil {
+				t.Errorf("%d %d VerifyControlBlock must fail with the other script", i, id)
+			}
+			// the parity of the output key
+			c[0] ^= 1
+			err = taproot.VerifyControlBlock(q, leaf.Script, c)
+			if err == nil {
+				t.Errorf("%d %d VerifyControlBlock must fail with the wrong parity", i, id)
+			}
+		}
+		t.Logf("%d ScriptTree Test Success / %x", i, root)
+	}
+}
+
+func TestHuffman(t *testing.T) {
+	weights := []int{1, 1, 2, 4, 8, 16}
+	// the expected depth of each leaf
+	depths := []int{5, 5, 4, 3, 2, 1}
+	leaves := []*taproot.Leaf{}
+	for i, w := range weights {
+		leaves = append(leaves, &taproot.Leaf{Version: taproot.LeafVersionTapScript, Script: []byte{0x01, byte(i), 0x75, 0x51}, Weight: w})
+	}
+	tree, err := taproot.Huffman(leaves)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if len(tree.Leaves()) != len(leaves) {
+		t.Fatalf("the number of leaves %d != %d", len(tree.Leaves()), len(leaves))
+	}
+	pubkey := make([]byte, 32)
+	rand.Read(pubkey)
+	for {
+		_, _, err = taproot.TweakPubKey(pubkey, tree.Hash())
+		if err == nil {
+			break
+		}
+		rand.Read(pubkey)
+	}
+	q, err := taproot.WitnessProgram(pubkey, tree.Hash())
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	for i, leaf := range leaves {
+		c, err := tree.ControlBlock(pubkey, leaf)
+		if err != nil {
+			t.Errorf("%d %v", i, err)
+			continue
+		}
+		if depth := (len(c) - 33) / 32; depth != depths[i] {
+			t.Errorf("%d depth %d != %d", i, depth, depths[i])
+			continue
+		}
+		err = taproot.VerifyControlBlock(q, leaf.Script, c)
+		if err != nil {
+			t.Errorf("%d %v", i, err)
+			continue
+		}
+		t.Logf("%d Huffman Test Success / weight %d depth %d", i, leaf.Weight, depths[i])
+	}
+	// the leaf not in the tree
+	_, err = tree.ControlBlock(pubkey, &taproot.Leaf{Version: taproot.LeafVersionTapScript, Script: []byte{0x51}, Weight: 1})
+	if err == nil {
+		t.Errorf("ControlBlock must fail with the leaf not in the tree")
+	}
+	// illegal weight
+	_, err = taproot.Huffman([]*taproot.Leaf{{Version: taproot.LeafVersionTapScript, Script: []byte{0x51}}})
+	if err == nil {
+		t.Errorf("Huffman must fail with the weight 0")
+	}
+}